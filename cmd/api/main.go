@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/api"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "Address to listen on")
+	flag.Parse()
+
+	// Deployments pass the database settings in the environment, a .env file
+	// is only a convenience for local runs.
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatalf("Failed to load env file: %s", err)
+	}
+
+	dbConfig, err := database.ConfigFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	db, err := database.NewDB(dbConfig)
+	if err != nil {
+		log.Fatalf("Failed to connect to the database: %s", err)
+	}
+	defer db.Close()
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           logRequests(api.NewServer(db)),
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Error shutting down: %s\n", err)
+		}
+	}()
+
+	log.Printf("Serving the fund API on %s\n", *addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("Failed to serve: %s", err)
+	}
+	log.Println("Stopped serving")
+}
+
+// statusRecorder remembers the status code a handler wrote.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		log.Printf("%s %s %d %s\n", r.Method, r.URL.RequestURI(), recorder.status, time.Since(start).Round(time.Millisecond))
+	})
+}
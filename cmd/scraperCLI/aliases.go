@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+)
+
+func aliases(ctx context.Context, db database.Store, args []string) error {
+	if len(args) == 0 {
+		return listAliases(ctx, db, "")
+	}
+
+	switch args[0] {
+	case "list":
+		status := ""
+		if len(args) > 1 {
+			status = args[1]
+		}
+		return listAliases(ctx, db, status)
+	case "unmatched":
+		return listUnmatched(ctx, db)
+	case "confirm":
+		return setAliasStatus(ctx, db, args[0], database.AliasStatusConfirmed, args[1:])
+	case "reject":
+		return setAliasStatus(ctx, db, args[0], database.AliasStatusRejected, args[1:])
+	default:
+		return fmt.Errorf("unknown aliases command %q", args[0])
+	}
+}
+
+func listAliases(ctx context.Context, db database.Store, status string) error {
+	fundAliases, err := db.ListFundNameAliasesContext(ctx, status)
+	if err != nil {
+		return err
+	}
+
+	fundNames, err := db.GetAllFundNamesContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tSCRAPED NAME\tTRUST NO\tFUND\tSOURCE\tBY\tUPDATED")
+	for _, alias := range fundAliases {
+		confirmedBy := ""
+		if alias.ConfirmedBy != nil {
+			confirmedBy = *alias.ConfirmedBy
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			alias.Status, alias.ScrapedName, alias.TrustNo, fundNames[alias.TrustNo], alias.Source, confirmedBy,
+			alias.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	}
+
+	return w.Flush()
+}
+
+// listUnmatched prints the fund names the latest -prices run could not match.
+func listUnmatched(ctx context.Context, db database.Store) error {
+	run, err := db.GetLatestScrapeRunContext(ctx, "prices")
+	if err != nil {
+		return err
+	}
+
+	if run == nil {
+		return fmt.Errorf("no prices run recorded yet")
+	}
+
+	items, err := db.GetScrapeRunItemsContext(ctx, run.ID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Unmatched fund names from prices run %d:\n", run.ID)
+
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if item.Status != database.ItemStatusUnmatched || seen[item.Item] {
+			continue
+		}
+		seen[item.Item] = true
+		fmt.Printf("  %s\n", item.Item)
+	}
+
+	if len(seen) > 0 {
+		fmt.Println("Map a name with: scraperCLI aliases confirm \"<scraped name>\" <trust no>")
+	}
+
+	return nil
+}
+
+func setAliasStatus(ctx context.Context, db database.Store, command string, status string, args []string) error {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	by := flags.String("by", currentUser(), "Who is confirming or rejecting the mapping")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 2 {
+		return fmt.Errorf("usage: aliases %s [-by name] \"<scraped name>\" <trust no>", command)
+	}
+
+	scrapedName := flags.Arg(0)
+	trustNo, err := strconv.Atoi(flags.Arg(1))
+	if err != nil {
+		return fmt.Errorf("error invalid trust no: %s : %w", flags.Arg(1), err)
+	}
+
+	if err := db.SetFundNameAliasStatusContext(ctx, scrapedName, trustNo, status, *by); err != nil {
+		return err
+	}
+
+	log.Printf("Marked %s -> %d as %s\n", scrapedName, trustNo, status)
+	return nil
+}
+
+func currentUser() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/analytics"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+// analyse prints the returns and risk of a fund class over the standard
+// horizons.
+func analyse(ctx context.Context, db database.ReadStore, args []string) error {
+	flags := flag.NewFlagSet("analytics", flag.ContinueOnError)
+	asOf := flags.String("as-of", "", "Last price date (YYYY-MM-DD) to report on, defaults to the latest price")
+	riskFree := flags.Float64("risk-free", 0, "Annual risk-free rate Sharpe and Sortino are measured against, 0.08 for 8%")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: analytics [-as-of date] [-risk-free rate] <fund class id>")
+	}
+
+	fundClassID, err := strconv.Atoi(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("error invalid fund class id: %s : %w", flags.Arg(0), err)
+	}
+
+	var to *models.Date
+	if *asOf != "" {
+		date, err := models.ParseDate(*asOf)
+		if err != nil {
+			return err
+		}
+		to = &date
+	}
+
+	calculator := analytics.Calculator{RiskFreeRate: *riskFree}
+	report, err := calculator.FundClassContext(ctx, db, fundClassID, to)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Fund class %d, NAV %.2f on %s, risk-free rate %s\n", report.FundClassID, report.NAV, report.AsOf, percent(&report.RiskFreeRate))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HORIZON\tFROM\tRETURN\tANNUALISED\tVOLATILITY\tMAX DRAWDOWN\tSHARPE\tSORTINO\tPRICES\tLONGEST GAP")
+	for _, period := range report.Periods {
+		s := period.Stats
+		if s == nil {
+			fmt.Fprintf(w, "%s\t-\tno price near %s\n", period.Horizon, period.Start)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%dd\n",
+			period.Horizon, s.BaseDate, percent(&s.CumulativeReturn), percent(s.AnnualisedReturn), percent(s.Volatility),
+			percent(&s.MaxDrawdown), ratio(s.Sharpe), ratio(s.Sortino), s.Observations, s.LongestGapDays)
+	}
+
+	return w.Flush()
+}
+
+func percent(f *float64) string {
+	if f == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", *f*100)
+}
+
+func ratio(f *float64) string {
+	if f == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", *f)
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/scraper"
+)
+
+type managerFailure struct {
+	managerID int
+	err       error
+}
+
+// fundSession is one worker's view of HistPriceLookUp.aspx. It reuses the
+// ViewState returned by its previous post-back and only fetches a fresh page
+// when it has none or the last request failed. A post-back that fails with a
+// reused ViewState is sent once more from a fresh page, as the site may have
+// expired it.
+type fundSession struct {
+	client    *scraper.Client
+	endpoints *scraper.Endpoints
+	viewState *scraper.ViewStateData
+}
+
+func (s *fundSession) scrapeManager(ctx context.Context, db database.Store, ledger *runLedger, managerID int) (int, error) {
+	reused := s.viewState != nil
+
+	fundHtml, err := s.postManager(ctx, managerID)
+	if err != nil && reused && ctx.Err() == nil {
+		log.Printf("Post-back for manager ID %d failed with a reused view state, retrying from a fresh page: %s\n", managerID, err)
+		fundHtml, err = s.postManager(ctx, managerID)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if viewState, err := scraper.ExtractViewStateData(fundHtml); err == nil && viewState.ViewState != "" {
+		s.viewState = viewState
+	} else {
+		s.viewState = nil
+	}
+
+	funds, diagnostics, err := scraper.ScrapeFunds(fundHtml, s.endpoints.Fields, managerID)
+	if err != nil {
+		return 0, fmt.Errorf("error scraping funds from html for ID - %d : %s", managerID, err)
+	}
+
+	ledger.diagnose(fmt.Sprintf("manager %d", managerID), diagnostics)
+
+	if len(funds) > 0 {
+		if err := db.SaveFundsContext(ctx, funds); err != nil {
+			return 0, fmt.Errorf("error saving funds : %w", err)
+		}
+	}
+
+	return len(funds), nil
+}
+
+// postManager posts the manager's fund lookup, fetching the page first when
+// the session has no ViewState. A failed post-back clears the ViewState.
+func (s *fundSession) postManager(ctx context.Context, managerID int) ([]byte, error) {
+	if s.viewState == nil {
+		initialHTML, err := s.client.GetContext(ctx, s.endpoints.HistPriceLookUpURL())
+		if err != nil {
+			return nil, fmt.Errorf("error fetching initial page: %w", err)
+		}
+
+		viewState, err := scraper.ExtractViewStateData(initialHTML)
+		if err != nil {
+			return nil, fmt.Errorf("error extracting the view state: %s", err)
+		}
+		s.viewState = viewState
+	}
+
+	formData := scraper.BuildFormData(s.viewState, s.endpoints.Fields, managerID)
+
+	fundHtml, err := s.client.PostContext(ctx, s.endpoints.HistPriceLookUpURL(), formData)
+	if err != nil {
+		s.viewState = nil
+		return nil, fmt.Errorf("error posting form for manager: %w", err)
+	}
+
+	return fundHtml, nil
+}
+
+func scrapeFundsForMangers(ctx context.Context, client *scraper.Client, endpoints *scraper.Endpoints, db database.Store, ledger *runLedger, mancoIds *string, workers int, continueOnError bool) error {
+	log.Println("Fetching funds for managers...")
+
+	var managerIdsToProcess []int
+	if *mancoIds != "" {
+		ids, err := parseMancoIDs(*mancoIds)
+		if err != nil {
+			return err
+		}
+		managerIdsToProcess = ids
+		log.Printf("Processing funds for %d specific managers\n", len(managerIdsToProcess))
+	} else {
+		mancoMangers, err := db.GetAllCISManagersContext(ctx)
+		if err != nil {
+			return fmt.Errorf("error getting cismanagers from db: %s", err)
+		}
+
+		for _, manager := range mancoMangers {
+			managerIdsToProcess = append(managerIdsToProcess, manager.ID)
+		}
+		log.Printf("Processing funds for all %d managers\n", len(managerIdsToProcess))
+	}
+
+	pending := make([]int, 0, len(managerIdsToProcess))
+	for _, managerID := range managerIdsToProcess {
+		if !ledger.isCompleted(managerCheckpoint(managerID)) {
+			pending = append(pending, managerID)
+		}
+	}
+
+	if skipped := len(managerIdsToProcess) - len(pending); skipped > 0 {
+		log.Printf("Skipping %d managers completed by the resumed run\n", skipped)
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Without -continue-on-error the first failure stops new managers from
+	// being dispatched, managers already in flight still finish and checkpoint.
+	dispatchCtx, stopDispatch := context.WithCancel(ctx)
+	defer stopDispatch()
+
+	jobs := make(chan int)
+	var processed atomic.Int32
+	var mu sync.Mutex
+	var failures []managerFailure
+	var wg sync.WaitGroup
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			session := &fundSession{client: client, endpoints: endpoints}
+			for managerID := range jobs {
+				count, err := session.scrapeManager(ctx, db, ledger, managerID)
+				if ctx.Err() != nil {
+					return
+				}
+				n := processed.Add(1)
+
+				item := fmt.Sprintf("manager %d", managerID)
+				if err != nil {
+					log.Printf("[%d/%d] Failed manager ID %d: %s\n", n, len(pending), managerID, err)
+					ledger.record(item, database.ItemStatusError, err.Error(), nil)
+					mu.Lock()
+					failures = append(failures, managerFailure{managerID: managerID, err: err})
+					mu.Unlock()
+
+					if !continueOnError {
+						stopDispatch()
+					}
+					continue
+				}
+
+				log.Printf("[%d/%d] Saved %d funds for manager ID: %d\n", n, len(pending), count, managerID)
+				ledger.record(item, database.ItemStatusOK, fmt.Sprintf("saved %d funds", count), func(run *models.ScrapeRun) {
+					run.SavedFunds += count
+				})
+				ledger.checkpoint(managerCheckpoint(managerID))
+			}
+		}()
+	}
+
+dispatch:
+	for _, managerID := range pending {
+		select {
+		case jobs <- managerID:
+		case <-dispatchCtx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		log.Printf("Interrupted after completing %d of %d managers (%d failed), rerun with -resume to continue\n",
+			processed.Load(), len(pending), len(failures))
+		return ctx.Err()
+	}
+
+	if len(failures) > 0 && !continueOnError {
+		return fmt.Errorf("stopped after manager %d failed, rerun with -resume to continue: %w",
+			failures[0].managerID, failures[0].err)
+	}
+
+	if len(failures) > 0 {
+		errs := make([]error, 0, len(failures))
+		for _, failure := range failures {
+			errs = append(errs, fmt.Errorf("manager %d: %w", failure.managerID, failure.err))
+		}
+		return fmt.Errorf("failed to scrape %d of %d managers, rerun with -resume to retry them: %w",
+			len(failures), len(pending), errors.Join(errs...))
+	}
+
+	log.Println("Completed the scraping of funds")
+	return nil
+}
+
+func managerCheckpoint(managerID int) string {
+	return "manager:" + strconv.Itoa(managerID)
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/asisatest"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/scraper"
+)
+
+func day(date string) time.Time {
+	parsed, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+func history(start string, navs ...float64) []asisatest.Price {
+	prices := make([]asisatest.Price, 0, len(navs))
+	for i, nav := range navs {
+		prices = append(prices, asisatest.Price{Date: day(start).AddDate(0, 0, i), NAV: nav})
+	}
+	return prices
+}
+
+// testScenario has two managers that both distribute an "Income Provider
+// Fund", a fund listed under a shorter name on LatestPrices, and one listed
+// name that matches nothing.
+func testScenario() *asisatest.Scenario {
+	priced := func(name string, nav string) asisatest.Class {
+		return asisatest.Class{
+			Name:         name,
+			TargetMarket: "Retail",
+			MaxInitFee:   "0.00%",
+			TICDate:      day("2024-09-30"),
+			TER:          "1.02%",
+			TC:           "0.08%",
+			TIC:          "1.10%",
+			PriceDate:    day("2024-10-17"),
+			NAV:          nav,
+		}
+	}
+
+	balancedA := priced("A", "124.57")
+	balancedA.History = history("2024-10-01", 123.10, 123.42, 124.01)
+	balancedC := priced("C", "125.04")
+	balancedC.History = history("2024-10-01", 123.55, 123.88, 124.20)
+
+	return &asisatest.Scenario{
+		Managers: []asisatest.Manager{
+			{
+				ID:   303,
+				Name: "Allan Gray Unit Trust Management (RF) (Pty) Ltd",
+				Funds: []asisatest.Fund{
+					{TrustNo: 1234, Name: "Allan Gray Balanced Fund", ListedName: "Allan Gray Balanced", Category: "South African - Multi Asset - High Equity", Classes: []asisatest.Class{balancedA, balancedC}},
+					{TrustNo: 1235, Name: "Allan Gray Equity Fund", Category: "South African - Equity - General", Classes: []asisatest.Class{priced("A", "512.30")}},
+					{TrustNo: 1236, Name: "Income Provider Fund", Category: "South African - Multi Asset - Income", Classes: []asisatest.Class{priced("A", "10.12")}},
+				},
+			},
+			{
+				ID:   37,
+				Name: "Coronation Management Company (RF) (Pty) Ltd",
+				Funds: []asisatest.Fund{
+					{TrustNo: 2001, Name: "Coronation Top 20 Fund", ListedName: "Coronation Top 20", Category: "South African - Equity - General", Classes: []asisatest.Class{priced("A", "n/a")}},
+					{TrustNo: 2002, Name: "Income Provider Fund", Category: "South African - Multi Asset - Income", Classes: []asisatest.Class{priced("P", "11.48")}},
+					{TrustNo: 2003, Name: "Coronation Optimum Growth Fund", ListedName: "Kagiso Islamic Equity", Category: "Global - Equity - General", Classes: []asisatest.Class{priced("A", "88.10")}},
+				},
+			},
+		},
+	}
+}
+
+func TestScrapeAgainstFakeSite(t *testing.T) {
+	server := asisatest.NewServer(testScenario())
+	defer server.Close()
+
+	ctx := context.Background()
+	db := database.NewMemoryStore()
+	endpoints := server.Endpoints()
+	client := scraper.NewClient(scraper.WithRetries(1))
+	noMancoIDs := ""
+
+	err := withRun(ctx, db, "manco", 1, func(ledger *runLedger) error {
+		return scrapeFundManagers(ctx, client, endpoints, db, ledger)
+	})
+	if err != nil {
+		t.Fatalf("manco: %v", err)
+	}
+
+	err = withRun(ctx, db, "funds", 1, func(ledger *runLedger) error {
+		return scrapeFundsForMangers(ctx, client, endpoints, db, ledger, &noMancoIDs, 2, false)
+	})
+	if err != nil {
+		t.Fatalf("funds: %v", err)
+	}
+
+	err = withRun(ctx, db, "prices", 1, func(ledger *runLedger) error {
+		return scrapeLatestPrices(ctx, client, endpoints, db, ledger, scraper.DefaultDateParser, 0.9, 0.7)
+	})
+	if err != nil {
+		t.Fatalf("prices: %v", err)
+	}
+
+	err = withRun(ctx, db, "backfill", 1, func(ledger *runLedger) error {
+		return backfillHistoricalPrices(ctx, client, endpoints, db, ledger, scraper.DefaultDateParser, &noMancoIDs, day("2024-10-01"), day("2024-10-31"))
+	})
+	if err != nil {
+		t.Fatalf("backfill: %v", err)
+	}
+
+	managers, err := db.GetAllCISManagersContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(managers) != 2 {
+		t.Errorf("saved %d managers, want 2", len(managers))
+	}
+
+	funds, err := db.GetAllFundsContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(funds) != 6 {
+		t.Errorf("saved %d funds, want 6", len(funds))
+	}
+
+	wantRuns := map[string]models.ScrapeRun{
+		"manco":    {SavedManagers: 2},
+		"funds":    {SavedFunds: 6},
+		"prices":   {Matched: 6, Unmatched: 1, SavedPrices: 5, SavedCosts: 6},
+		"backfill": {SavedPrices: 6},
+	}
+	for mode, want := range wantRuns {
+		run, err := db.GetLatestScrapeRunContext(ctx, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if run == nil || run.Status != database.RunStatusSucceeded {
+			t.Errorf("%s run = %+v, want a succeeded run", mode, run)
+			continue
+		}
+		if run.SavedManagers != want.SavedManagers || run.SavedFunds != want.SavedFunds || run.Matched != want.Matched ||
+			run.Unmatched != want.Unmatched || run.SavedPrices != want.SavedPrices || run.SavedCosts != want.SavedCosts {
+			t.Errorf("%s run counters = %+v, want %+v", mode, run, want)
+		}
+	}
+
+	// Same-named funds are matched within the manager they are listed under.
+	wantClasses := map[int][]string{
+		1234: {"Class A", "Class C"},
+		1235: {"Class A"},
+		1236: {"Class A"},
+		2001: {"Class A"},
+		2002: {"Class P"},
+		2003: nil,
+	}
+	for trustNo, want := range wantClasses {
+		classes, err := db.GetFundClassesByFundContext(ctx, trustNo)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, class := range classes {
+			got = append(got, class.ClassName)
+		}
+		if len(got) != len(want) {
+			t.Errorf("fund %d classes = %v, want %v", trustNo, got, want)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("fund %d classes = %v, want %v", trustNo, got, want)
+				break
+			}
+		}
+	}
+
+	if got := server.Requests("GET", "/LatestPrices.aspx"); got != 1 {
+		t.Errorf("LatestPrices fetched %d times, want 1", got)
+	}
+}
+
+func TestScrapeFundsRejectsStaleViewState(t *testing.T) {
+	server := asisatest.NewServer(testScenario())
+	defer server.Close()
+
+	ctx := context.Background()
+	db := database.NewMemoryStore()
+	client := scraper.NewClient(scraper.WithRetries(1))
+
+	if err := db.SaveCISManagersContext(ctx, []*models.CISManager{{ID: 303, Name: "Allan Gray"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	ledger, err := startRun(ctx, db, "funds", 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The site rejects the stale ViewState, so the manager is posted again
+	// from a freshly fetched page rather than failed.
+	session := &fundSession{client: client, endpoints: server.Endpoints(), viewState: &scraper.ViewStateData{ViewState: "stale"}}
+	count, err := session.scrapeManager(ctx, db, ledger, 303)
+	if err != nil {
+		t.Fatalf("scrapeManager with a stale ViewState: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("saved %d funds, want 3", count)
+	}
+
+	path := server.Endpoints().HistPriceLookUpPath
+	if gets, posts := server.Requests("GET", path), server.Requests("POST", path); gets != 1 || posts != 2 {
+		t.Errorf("fetched the page %d times and posted %d times, want 1 fetch and 2 posts", gets, posts)
+	}
+	if session.viewState == nil || session.viewState.ViewState == "stale" {
+		t.Errorf("session holds ViewState %+v, want the one from the retried post-back", session.viewState)
+	}
+}
+
+func TestScrapeFundsWithConfiguredEndpoints(t *testing.T) {
+	endpoints := scraper.DefaultEndpoints()
+	endpoints.HistPriceLookUpPath = "/v2/Lookup.aspx"
+	endpoints.Fields = scraper.FormFields{ManagerID: "ctl00$ManCo", TrustNo: "ctl00$Fund", StartDate: "ctl00$From", EndDate: "ctl00$To"}
+
+	server := asisatest.NewServerWithEndpoints(testScenario(), endpoints)
+	defer server.Close()
+
+	ctx := context.Background()
+	db := database.NewMemoryStore()
+	client := scraper.NewClient(scraper.WithRetries(1))
+	noMancoIDs := ""
+
+	err := withRun(ctx, db, "manco", 1, func(ledger *runLedger) error {
+		return scrapeFundManagers(ctx, client, server.Endpoints(), db, ledger)
+	})
+	if err != nil {
+		t.Fatalf("manco: %v", err)
+	}
+
+	err = withRun(ctx, db, "funds", 1, func(ledger *runLedger) error {
+		return scrapeFundsForMangers(ctx, client, server.Endpoints(), db, ledger, &noMancoIDs, 1, false)
+	})
+	if err != nil {
+		t.Fatalf("funds: %v", err)
+	}
+
+	funds, err := db.GetAllFundsContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(funds) != 6 {
+		t.Errorf("saved %d funds, want 6", len(funds))
+	}
+
+	if got := server.Requests("POST", "/v2/Lookup.aspx"); got != 2 {
+		t.Errorf("posted to the configured path %d times, want 2", got)
+	}
+}
@@ -1,15 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
 	"github.com/Alexandervanderleek/FundFinderZA/internal/scraper"
 	"github.com/joho/godotenv"
 )
@@ -19,32 +24,62 @@ func main() {
 	scrapeManco := flag.Bool("manco", false, "Scrape & Save CIS managers only")
 	scrapeFunds := flag.Bool("funds", false, "Scrape & Save funds for saved managers")
 	scrapePrices := flag.Bool("prices", false, "Scrape & Save all fund prices per class of fund")
+	backfillPrices := flag.Bool("backfill", false, "Scrape & Save the full historical price series per class of fund")
 	mancoIDs := flag.String("manco-ids", "", "Comman-seperated list of manco Ids to scrape and update.")
+	fromDate := flag.String("from", time.Now().AddDate(-5, 0, 0).Format("2006-01-02"), "Start date (YYYY-MM-DD) of the backfill range")
+	toDate := flag.String("to", time.Now().Format("2006-01-02"), "End date (YYYY-MM-DD) of the backfill range")
+	workers := flag.Int("workers", 4, "Number of managers to scrape funds for concurrently")
+	rate := flag.Float64("rate", 1, "Maximum requests per second sent to the site, 0 disables the limit")
+	resume := flag.Bool("resume", false, "Continue the last unfinished -funds run from its checkpoints")
+	continueOnError := flag.Bool("continue-on-error", false, "Keep scraping the remaining managers when one fails")
+	matchAccept := flag.Float64("match-accept", 0.9, "Minimum fuzzy match score (0-1) to attach prices to a fund without review")
+	matchReview := flag.Float64("match-review", 0.7, "Minimum fuzzy match score (0-1) to propose an alias for review")
+	maxDropped := flag.Float64("max-dropped", 1, "Fail a run when more than this share (0-1) of its scraped rows was dropped, 1 never fails")
+	centuryPivot := flag.Int("century-pivot", scraper.DefaultCenturyPivot, "Last two digit year (1-99) read as 20xx in scraped dates, later years are 19xx")
+	endpointsFile := flag.String("endpoints", "", "JSON file of site endpoints and form field names, defaults to $ASISA_ENDPOINTS_CONFIG")
+	baseURL := flag.String("base-url", "", "Base URL of the ASISA pages, overrides the endpoints config and $ASISA_BASE_URL")
+	recordDir := flag.String("record", "", "Directory to archive every fetched page in")
+	replayDir := flag.String("replay", "", "Directory of archived pages to serve instead of the network")
 
 	flag.Parse()
 
-	if !*scrapeFunds && !*scrapeManco && !*scrapePrices {
-		log.Println("Usage: scraperCLI -manco | -funds | -prices [-manco-ids=0303,0037]")
+	if !*scrapeFunds && !*scrapeManco && !*scrapePrices && !*backfillPrices && flag.NArg() == 0 {
+		log.Println("Usage: scraperCLI -manco | -funds | -prices | -backfill [-manco-ids=0303,0037] [-from=2020-01-01 -to=2025-01-01] [-match-accept=0.9 -match-review=0.7] [-century-pivot=50] [-max-dropped=0.05] [-resume] [-continue-on-error] [-record=dir | -replay=dir] [-endpoints=file] [-base-url=url]")
+		log.Println("       scraperCLI migrate up | down [steps] | version")
+		log.Println("       scraperCLI runs [run-id]")
+		log.Println("       scraperCLI aliases [list [status] | unmatched | confirm|reject [-by name] \"<scraped name>\" <trust no>]")
+		log.Println("       scraperCLI screen [-sort [-]field] [-limit n] \"<filter>\"")
+		log.Println("       scraperCLI analytics [-as-of=2024-10-17] [-risk-free=0.08] <fund class id>")
+		log.Println("       scraperCLI peers snapshot [-as-of=2024-10-17] | show <fund class id> | history [-metric=return_3y] <fund class id>")
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
 
+	if *maxDropped < 0 || *maxDropped > 1 {
+		log.Fatalln("-max-dropped must be between 0 and 1")
+	}
+
+	dates, err := scraper.NewDateParser(*centuryPivot)
+	if err != nil {
+		log.Fatalf("Invalid -century-pivot: %s", err)
+	}
+
+	if *recordDir != "" && *replayDir != "" {
+		log.Fatalln("-record and -replay cannot be used together")
+	}
+
 	if err := godotenv.Load(); err != nil {
 		log.Fatalln("Failed to load env file")
 	}
 
-	port, err := strconv.Atoi(os.Getenv("DB_PORT"))
+	endpoints, err := loadEndpoints(*endpointsFile, *baseURL)
 	if err != nil {
-		log.Fatalln("Failed to parse database port")
+		log.Fatalln(err)
 	}
 
-	dbConfig := &database.DbConfig{
-		Host:     os.Getenv("DB_HOST"),
-		Port:     port,
-		User:     os.Getenv("DB_USER"),
-		Password: os.Getenv("DB_PASSWORD"),
-		DBName:   os.Getenv("DB_NAME"),
-		SSLMode:  os.Getenv("SSLMode"),
+	dbConfig, err := database.ConfigFromEnv()
+	if err != nil {
+		log.Fatalln(err)
 	}
 
 	newDb, err := database.NewDB(dbConfig)
@@ -52,169 +87,304 @@ func main() {
 		log.Fatalf("Failed to connect to the database: %s", err)
 	}
 
-	httpClient := scraper.NewClient(
-		scraper.WithRetries(1),
+	if flag.NArg() > 0 {
+		if err := runCommand(newDb, flag.Args()); err != nil {
+			log.Fatalf("Failed to run %s: %s", flag.Arg(0), err)
+		}
+		return
+	}
+
+	clientOptions := []scraper.ClientOption{
+		scraper.WithRetries(3),
 		scraper.WithUserAgent("MyCustomUserAgent/1.0"),
-		scraper.WithTimeout(30*time.Second))
+		scraper.WithTimeout(30 * time.Second),
+		scraper.WithRateLimit(*rate, 1),
+	}
+
+	if *recordDir != "" {
+		archive, err := scraper.NewArchive(*recordDir)
+		if err != nil {
+			log.Fatalf("Failed to open record archive: %s", err)
+		}
+		clientOptions = append(clientOptions, scraper.WithRecording(archive))
+	}
+
+	if *replayDir != "" {
+		archive, err := scraper.NewArchive(*replayDir)
+		if err != nil {
+			log.Fatalf("Failed to open replay archive: %s", err)
+		}
+		clientOptions = append(clientOptions, scraper.WithReplay(archive))
+	}
+
+	httpClient := scraper.NewClient(clientOptions...)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	if *scrapeManco {
-		if err := scrapeFundManagers(httpClient, newDb); err != nil {
-			log.Fatalf("Failed to scrape fund managers: %s", err)
+		err := withRun(ctx, newDb, "manco", *maxDropped, func(ledger *runLedger) error {
+			return scrapeFundManagers(ctx, httpClient, endpoints, newDb, ledger)
+		})
+		if err != nil {
+			exitOnError("Failed to scrape fund managers", err)
 		}
 	}
 
 	if *scrapeFunds {
-		if err := scrapeFundsForMangers(httpClient, newDb, mancoIDs); err != nil {
-			log.Fatalf("Failed to scrape funds for managers: %s", err)
+		err := withResumableRun(ctx, newDb, "funds", *maxDropped, *resume, func(ledger *runLedger) error {
+			return scrapeFundsForMangers(ctx, httpClient, endpoints, newDb, ledger, mancoIDs, *workers, *continueOnError)
+		})
+		if err != nil {
+			exitOnError("Failed to scrape funds for managers", err)
 		}
 	}
 
 	if *scrapePrices {
-		if err := ScrapeHistoricalPrices(httpClient, newDb); err != nil {
-			log.Fatalf("Failed to scrape historical prices: %s", err)
+		err := withRun(ctx, newDb, "prices", *maxDropped, func(ledger *runLedger) error {
+			return scrapeLatestPrices(ctx, httpClient, endpoints, newDb, ledger, dates, *matchAccept, *matchReview)
+		})
+		if err != nil {
+			exitOnError("Failed to scrape latest prices", err)
 		}
 	}
-}
 
-func scrapeFundManagers(client *scraper.Client, db *database.DB) error {
-	log.Println("Fetching CIS managers...")
+	if *backfillPrices {
+		from, err := time.Parse("2006-01-02", *fromDate)
+		if err != nil {
+			log.Fatalf("Invalid -from date: %s", err)
+		}
 
-	byteBody, err := client.Get("https://funds.profiledata.co.za/aci/ASISA/HistPriceLookUp.aspx")
-	if err != nil {
-		return fmt.Errorf("error fetching page: %s", err)
+		to, err := time.Parse("2006-01-02", *toDate)
+		if err != nil {
+			log.Fatalf("Invalid -to date: %s", err)
+		}
+
+		err = withRun(ctx, newDb, "backfill", *maxDropped, func(ledger *runLedger) error {
+			return backfillHistoricalPrices(ctx, httpClient, endpoints, newDb, ledger, dates, mancoIDs, from, to)
+		})
+		if err != nil {
+			exitOnError("Failed to backfill historical prices", err)
+		}
+	}
+}
+
+// exitOnError exits with the conventional SIGINT status when the run was
+// cancelled, so scripts can tell an interrupted run from a failed one.
+func exitOnError(message string, err error) {
+	if errors.Is(err, context.Canceled) {
+		log.Printf("%s: cancelled", message)
+		os.Exit(130)
 	}
+	log.Fatalf("%s: %s", message, err)
+}
 
-	cisMangers, err := scraper.ScrapeCISMangers(byteBody)
+func loadEndpoints(path string, baseURL string) (*scraper.Endpoints, error) {
+	if path == "" {
+		path = os.Getenv("ASISA_ENDPOINTS_CONFIG")
+	}
 
+	endpoints, err := scraper.LoadEndpoints(path)
 	if err != nil {
-		return fmt.Errorf("error scraping managers from page %s", err)
+		return nil, err
 	}
 
-	if err := db.SaveCISManagers(cisMangers); err != nil {
-		return fmt.Errorf("error saving scraped cisManger: %s", err)
+	if baseURL != "" {
+		endpoints.BaseURL = baseURL
+		if err := endpoints.Validate(); err != nil {
+			return nil, err
+		}
 	}
 
-	log.Printf("Succesfully saved %d fund managers\n", len(cisMangers))
-	return nil
+	return endpoints, nil
 }
 
-func scrapeFundsForMangers(client *scraper.Client, db *database.DB, mancoIds *string) error {
-	log.Println("Fetching funds for managers...")
+func runCommand(db *database.DB, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return migrate(db, args[1:])
+	case "runs":
+		return runs(context.Background(), db, args[1:])
+	case "aliases":
+		return aliases(context.Background(), db, args[1:])
+	case "screen":
+		return screen(context.Background(), db, args[1:])
+	case "analytics":
+		return analyse(context.Background(), db, args[1:])
+	case "peers":
+		return peers(context.Background(), db, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
 
-	var managerIdsToProcess []int
-	if *mancoIds != "" {
-		idStrs := strings.SplitSeq(*mancoIds, ",")
-		for idStr := range idStrs {
-			id, err := strconv.Atoi(idStr)
+func migrate(db *database.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up | down [steps] | version")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := db.MigrateUp()
+		if err != nil {
+			return err
+		}
+		log.Printf("Applied %d migrations\n", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			steps, err = strconv.Atoi(args[1])
 			if err != nil {
-				return fmt.Errorf("error invalid manco id: %s : %w", idStr, err)
+				return fmt.Errorf("error invalid steps: %s : %w", args[1], err)
 			}
-			managerIdsToProcess = append(managerIdsToProcess, id)
 		}
-		log.Printf("Processing funds for %d specific managers\n", len(managerIdsToProcess))
-	} else {
-		mancoMangers, err := db.GetAllCISManagers()
+
+		reverted, err := db.MigrateDown(steps)
 		if err != nil {
-			return fmt.Errorf("error getting cismanagers from db: %s", err)
+			return err
 		}
+		log.Printf("Reverted %d migrations\n", reverted)
+	case "version":
+	default:
+		return fmt.Errorf("unknown migrate direction %q", args[0])
+	}
 
-		for _, manager := range mancoMangers {
-			managerIdsToProcess = append(managerIdsToProcess, manager.ID)
-		}
-		log.Printf("Processing funds for all %d managers\n", len(managerIdsToProcess))
+	version, err := db.SchemaVersion()
+	if err != nil {
+		return err
 	}
+	log.Printf("Schema version: %d\n", version)
+	return nil
+}
 
-	for i, managerID := range managerIdsToProcess {
-		log.Printf("[%d/%d] Processing manager ID: %d \n", i+1, len(managerIdsToProcess), managerID)
+func scrapeFundManagers(ctx context.Context, client *scraper.Client, endpoints *scraper.Endpoints, db database.Store, ledger *runLedger) error {
+	log.Println("Fetching CIS managers...")
 
-		initialHTML, err := client.Get("https://funds.profiledata.co.za/aci/ASISA/HistPriceLookUp.aspx")
+	byteBody, err := client.GetContext(ctx, endpoints.HistPriceLookUpURL())
+	if err != nil {
+		return fmt.Errorf("error fetching page: %w", err)
+	}
 
-		if err != nil {
-			return fmt.Errorf("error fetching initial page: %s", err)
-		}
+	cisMangers, diagnostics, err := scraper.ScrapeCISMangers(byteBody, endpoints.Fields)
 
-		viewState, err := scraper.ExtractViewStateData(initialHTML)
-		if err != nil {
-			return fmt.Errorf("error extracting the view state: %s", err)
-		}
-
-		formData := scraper.BuildFormData(viewState, managerID)
+	if err != nil {
+		return fmt.Errorf("error scraping managers from page %s", err)
+	}
 
-		fundHtml, err := client.Post("https://funds.profiledata.co.za/aci/ASISA/HistPriceLookUp.aspx", formData)
-		if err != nil {
-			return fmt.Errorf("error posting form for manager: %s", err)
-		}
+	ledger.diagnose("managers", diagnostics)
 
-		funds, err := scraper.ScrapeFunds(fundHtml, managerID)
-		if err != nil {
-			return fmt.Errorf("error scraping funds from html for ID - %d : %s", managerID, err)
-		}
+	if err := db.SaveCISManagersContext(ctx, cisMangers); err != nil {
+		return fmt.Errorf("error saving scraped cisManger: %w", err)
+	}
 
-		if len(funds) > 0 {
-			if err := db.SaveFunds(funds); err != nil {
-				return fmt.Errorf("error saving funds : %s", err)
-			}
-		} else {
-			log.Printf("No funds found")
-		}
+	ledger.update(func(run *models.ScrapeRun) { run.SavedManagers = len(cisMangers) })
 
-		time.Sleep(1 * time.Second)
-	}
-	log.Println("Completed the scraping of funds")
+	log.Printf("Succesfully saved %d fund managers\n", len(cisMangers))
 	return nil
 }
 
-func ScrapeHistoricalPrices(client *scraper.Client, db *database.DB) error {
-	log.Println("Scraping Historical prices...")
+// listsManagers reports whether any row was listed under a manager.
+func listsManagers(rows []*scraper.FundPricingData) bool {
+	for _, row := range rows {
+		if row.ManagerName != "" {
+			return true
+		}
+	}
+	return false
+}
 
-	url := "https://funds.profiledata.co.za/aci/ASISA/LatestPrices.aspx"
+// scrapeLatestPrices saves the classes, costs and current price listed on
+// LatestPrices. The full price history comes from backfillHistoricalPrices.
+func scrapeLatestPrices(ctx context.Context, client *scraper.Client, endpoints *scraper.Endpoints, db database.Store, ledger *runLedger, dates scraper.DateParser, acceptScore float64, reviewScore float64) error {
+	log.Println("Scraping latest prices...")
 
-	byteBody, err := client.Get(url)
+	byteBody, err := client.GetContext(ctx, endpoints.LatestPricesURL())
 	if err != nil {
-		return fmt.Errorf("error getting latest price page: %s", err)
+		return fmt.Errorf("error getting latest price page: %w", err)
 	}
 
-	currentPriceDate, err := scraper.ScrapeCurrentPriceAndCostData(byteBody)
+	currentPriceDate, diagnostics, err := scraper.ScrapeCurrentPriceAndCostData(byteBody, dates)
+	var layoutErr *scraper.LayoutError
+	if errors.As(err, &layoutErr) {
+		return fmt.Errorf("refusing to save prices, the LatestPrices table has changed: %w", err)
+	}
 	if err != nil {
-		return fmt.Errorf("error parsing scraped html: %s", err)
+		return fmt.Errorf("error parsing scraped html: %w", err)
 	}
 
 	log.Printf("Scraped %d fund classes from prices page \n", len(currentPriceDate))
 
+	ledger.diagnose("latest prices", diagnostics)
+
+	// The page is fetched once a run, so this warns once. Managers are read
+	// from tr.mancorow, a class no captured page has confirmed yet.
+	if len(currentPriceDate) > 0 && !listsManagers(currentPriceDate) {
+		log.Println("No manager rows (tr.mancorow) on the prices page, matching each fund against every manager's funds")
+	}
+
+	resolver, err := newFundResolver(ctx, db, acceptScore, reviewScore)
+	if err != nil {
+		return err
+	}
+	resolved := make(map[string]*fundMatch)
+
 	matchedCount := 0
 	unmatchedFunds := make([]string, 0)
 	savedPrices := 0
 	savedCosts := 0
 
-	for _, data := range currentPriceDate {
-		fundID, matchedName, err := db.FuzzyMatchFundName(data.FundClass.FundName)
-		if err != nil {
-			return fmt.Errorf("error fuzzy matching for fund: %s, %s", data.FundClass.FundName, err)
+	defer ledger.update(func(run *models.ScrapeRun) {
+		run.Matched = matchedCount
+		run.Unmatched = len(unmatchedFunds)
+		run.SavedPrices = savedPrices
+		run.SavedCosts = savedCosts
+	})
+
+	for i, data := range currentPriceDate {
+		if ctx.Err() != nil {
+			log.Printf("Interrupted after %d of %d fund classes: %d matched, %d unmatched, saved %d prices and %d costs\n",
+				i, len(currentPriceDate), matchedCount, len(unmatchedFunds), savedPrices, savedCosts)
+			return ctx.Err()
 		}
 
-		if fundID == 0 {
-			unmatchedFunds = append(unmatchedFunds, fmt.Sprintf("%s %s", data.FundClass.FundName, data.FundClass.ClassName))
-			continue
+		key := data.ManagerName + "\x00" + data.FundClass.FundName
+		match, ok := resolved[key]
+		if !ok {
+			match, err = resolver.resolve(ctx, data.FundClass.FundName, data.ManagerName)
+			if err != nil {
+				return fmt.Errorf("error fuzzy matching for fund: %s, %w", data.FundClass.FundName, err)
+			}
+			resolved[key] = match
+
+			if match.trustNo != 0 && match.matchedName != data.FundClass.FundName {
+				log.Printf("Fuzzy Matched: %s -> %s \n", data.FundClass.FundName, match.matchedName)
+			}
 		}
 
-		if matchedName != data.FundClass.FundName {
-			log.Printf("Fuzzy Matched: %s -> %s \n", data.FundClass.FundName, matchedName)
+		if match.trustNo == 0 {
+			name := fmt.Sprintf("%s %s", data.FundClass.FundName, data.FundClass.ClassName)
+			unmatchedFunds = append(unmatchedFunds, name)
+			ledger.record(data.FundClass.FundName, database.ItemStatusUnmatched, data.FundClass.ClassName+"; "+match.reason, nil)
+			continue
 		}
 
 		matchedCount++
-		data.FundClass.FundID = fundID
+		data.FundClass.FundID = match.trustNo
 
-		if err := db.SaveFundClass(data.FundClass); err != nil {
+		if err := db.SaveFundClassContext(ctx, data.FundClass); err != nil {
 			log.Printf("Error saving fund class for %s %s: %v\n",
 				data.FundClass.FundName, data.FundClass.ClassName, err)
+			ledger.record(data.FundClass.FundName+" "+data.FundClass.ClassName, database.ItemStatusError, "saving fund class: "+err.Error(), nil)
 			continue
 		}
 
 		if data.Costs.TICDate != nil {
 			data.Costs.FundClassID = data.FundClass.ID
-			if err := db.SaveFundClassCosts(data.Costs); err != nil {
+			if err := db.SaveFundClassCostsContext(ctx, data.Costs); err != nil {
 				log.Printf("Error saving costs for %s %s: %v\n",
 					data.FundClass.FundName, data.FundClass.ClassName, err)
+				ledger.record(data.FundClass.FundName+" "+data.FundClass.ClassName, database.ItemStatusError, "saving costs: "+err.Error(), nil)
 			} else {
 				savedCosts++
 			}
@@ -222,9 +392,10 @@ func ScrapeHistoricalPrices(client *scraper.Client, db *database.DB) error {
 
 		if data.Price.PriceDate != nil && data.Price.NAV != nil {
 			data.Price.FundClassID = data.FundClass.ID
-			if err := db.SaveFundClassPrice(data.Price); err != nil {
+			if err := db.SaveFundClassPriceContext(ctx, data.Price); err != nil {
 				log.Printf("Error saving price for %s %s: %v\n",
 					data.FundClass.FundName, data.FundClass.ClassName, err)
+				ledger.record(data.FundClass.FundName+" "+data.FundClass.ClassName, database.ItemStatusError, "saving price: "+err.Error(), nil)
 			} else {
 				savedPrices++
 			}
@@ -238,6 +409,151 @@ func ScrapeHistoricalPrices(client *scraper.Client, db *database.DB) error {
 		fmt.Printf("Fund %d: %s\n", i, fund)
 	}
 
+	log.Printf("Matched %d fund classes, saved %d prices and %d costs\n", matchedCount, savedPrices, savedCosts)
+
 	return nil
 
 }
+
+func backfillHistoricalPrices(ctx context.Context, client *scraper.Client, endpoints *scraper.Endpoints, db database.Store, ledger *runLedger, dates scraper.DateParser, mancoIds *string, from time.Time, to time.Time) error {
+	log.Printf("Backfilling historical prices from %s to %s...\n", from.Format("2006-01-02"), to.Format("2006-01-02"))
+
+	funds, err := db.GetAllFundsContext(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting funds from db: %s", err)
+	}
+
+	if *mancoIds != "" {
+		ids, err := parseMancoIDs(*mancoIds)
+		if err != nil {
+			return err
+		}
+
+		wanted := make(map[int]bool, len(ids))
+		for _, id := range ids {
+			wanted[id] = true
+		}
+
+		filtered := funds[:0]
+		for _, fund := range funds {
+			if wanted[fund.ManagerID] {
+				filtered = append(filtered, fund)
+			}
+		}
+		funds = filtered
+	}
+
+	log.Printf("Backfilling prices for %d funds\n", len(funds))
+
+	savedPrices := 0
+	unknownClasses := make([]string, 0)
+
+	defer ledger.update(func(run *models.ScrapeRun) {
+		run.SavedPrices = savedPrices
+		run.Unmatched = len(unknownClasses)
+	})
+
+	for i, fund := range funds {
+		if ctx.Err() != nil {
+			log.Printf("Interrupted after %d of %d funds: saved %d historical prices\n", i, len(funds), savedPrices)
+			return ctx.Err()
+		}
+
+		log.Printf("[%d/%d] Processing fund: %d %s \n", i+1, len(funds), fund.TrustNo, fund.Name)
+
+		initialHTML, err := client.GetContext(ctx, endpoints.HistPriceLookUpURL())
+		if err != nil {
+			return fmt.Errorf("error fetching initial page: %w", err)
+		}
+
+		viewState, err := scraper.ExtractViewStateData(initialHTML)
+		if err != nil {
+			return fmt.Errorf("error extracting the view state: %s", err)
+		}
+
+		fundHtml, err := client.PostContext(ctx, endpoints.HistPriceLookUpURL(), scraper.BuildFormData(viewState, endpoints.Fields, fund.ManagerID))
+		if err != nil {
+			return fmt.Errorf("error posting form for manager: %w", err)
+		}
+
+		viewState, err = scraper.ExtractViewStateData(fundHtml)
+		if err != nil {
+			return fmt.Errorf("error extracting the view state: %s", err)
+		}
+
+		formData := scraper.BuildPriceFormData(viewState, endpoints.Fields, fund.ManagerID, fund.TrustNo, from, to)
+
+		priceHtml, err := client.PostContext(ctx, endpoints.HistPriceLookUpURL(), formData)
+		if err != nil {
+			return fmt.Errorf("error posting form for fund: %w", err)
+		}
+
+		prices, diagnostics, err := scraper.ScrapeHistoricalPrices(priceHtml, dates)
+		if err != nil {
+			return fmt.Errorf("error scraping prices from html for fund - %d : %s", fund.TrustNo, err)
+		}
+
+		ledger.diagnose(fmt.Sprintf("fund %d", fund.TrustNo), diagnostics)
+
+		fundClasses, err := db.GetFundClassesByFundContext(ctx, fund.TrustNo)
+		if err != nil {
+			return err
+		}
+
+		classIDs := make(map[string]int, len(fundClasses))
+		for _, fundClass := range fundClasses {
+			classIDs[fundClass.ClassName] = fundClass.ID
+		}
+
+		fundPrices := 0
+		reported := make(map[string]bool)
+		for _, price := range prices {
+			classID, ok := classIDs[price.ClassName]
+			if !ok {
+				if !reported[price.ClassName] {
+					name := fmt.Sprintf("%s %s", fund.Name, price.ClassName)
+					unknownClasses = append(unknownClasses, name)
+					ledger.record(name, database.ItemStatusUnmatched, "class not saved yet, run -prices first", nil)
+					reported[price.ClassName] = true
+				}
+				continue
+			}
+
+			price.Price.FundClassID = classID
+			if err := db.SaveFundClassPriceContext(ctx, price.Price); err != nil {
+				log.Printf("Error saving price for %s %s: %v\n", fund.Name, price.ClassName, err)
+				ledger.record(fmt.Sprintf("%s %s", fund.Name, price.ClassName), database.ItemStatusError, "saving price: "+err.Error(), nil)
+				continue
+			}
+			savedPrices++
+			fundPrices++
+		}
+
+		ledger.record(fmt.Sprintf("fund %d", fund.TrustNo), database.ItemStatusOK, fmt.Sprintf("saved %d prices", fundPrices), nil)
+	}
+
+	log.Printf("Saved %d historical prices\n", savedPrices)
+
+	if len(unknownClasses) > 0 {
+		fmt.Println(strings.Repeat("=", 80))
+		fmt.Println("The following classes are not saved yet, run -prices first...")
+
+		for i, class := range unknownClasses {
+			fmt.Printf("Class %d: %s\n", i, class)
+		}
+	}
+
+	return nil
+}
+
+func parseMancoIDs(mancoIds string) ([]int, error) {
+	var ids []int
+	for idStr := range strings.SplitSeq(mancoIds, ",") {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			return nil, fmt.Errorf("error invalid manco id: %s : %w", idStr, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/matcher"
+)
+
+const matchCandidates = 3
+
+// acceptMargin is how far the best candidate must score above the runner-up
+// to be accepted without review. A name without a brand can fit the
+// same-named funds of several managers equally well, and picking one of
+// them would attach prices to the wrong fund.
+const acceptMargin = 0.05
+
+type fundMatch struct {
+	trustNo     int
+	matchedName string
+	// reason explains why a name was left unmatched, for the run ledger.
+	reason string
+}
+
+// fundResolver maps LatestPrices fund names to trust numbers. Confirmed
+// aliases win over everything, then exact names, then the scored matcher
+// which only auto-accepts above acceptScore and acceptMargin clear of the
+// runner-up, and proposes anything above reviewScore as an alias for review.
+// When the row's manager is known only that manager's funds are considered.
+type fundResolver struct {
+	db           database.Store
+	matcher      *matcher.Matcher
+	fundManagers map[int]int
+	acceptScore  float64
+	reviewScore  float64
+}
+
+func newFundResolver(ctx context.Context, db database.Store, acceptScore float64, reviewScore float64) (*fundResolver, error) {
+	funds, err := db.GetAllFundsContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting funds for matching: %w", err)
+	}
+
+	managers, err := db.GetAllCISManagersContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting managers for matching: %w", err)
+	}
+
+	fundManagers := make(map[int]int, len(funds))
+	for _, fund := range funds {
+		fundManagers[fund.TrustNo] = fund.ManagerID
+	}
+
+	return &fundResolver{
+		db:           db,
+		matcher:      matcher.New(funds, managers),
+		fundManagers: fundManagers,
+		acceptScore:  acceptScore,
+		reviewScore:  reviewScore,
+	}, nil
+}
+
+// resolve matches fundName as listed under managerName, which may be empty.
+func (r *fundResolver) resolve(ctx context.Context, fundName string, managerName string) (*fundMatch, error) {
+	managerID := r.matcher.ManagerID(managerName)
+
+	aliases, err := r.db.GetFundNameAliasesContext(ctx, fundName)
+	if err != nil {
+		return nil, err
+	}
+
+	rejected := make(map[int]bool)
+	for _, alias := range aliases {
+		switch alias.Status {
+		case database.AliasStatusConfirmed:
+			if r.belongsTo(alias.TrustNo, managerID) {
+				return &fundMatch{trustNo: alias.TrustNo, matchedName: fundName}, nil
+			}
+		case database.AliasStatusRejected:
+			rejected[alias.TrustNo] = true
+		}
+	}
+
+	fundID, err := r.db.GetFundByNameContext(ctx, fundName)
+	if err != nil {
+		return nil, err
+	}
+
+	if fundID != 0 && !rejected[fundID] && r.belongsTo(fundID, managerID) {
+		return &fundMatch{trustNo: fundID, matchedName: fundName}, nil
+	}
+
+	var candidates []matcher.Match
+	for _, candidate := range r.matcher.RankManager(fundName, managerID, matchCandidates+len(rejected)) {
+		if !rejected[candidate.TrustNo] && len(candidates) < matchCandidates {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	if len(candidates) == 0 || candidates[0].Score < r.reviewScore {
+		reason := "no candidate above review score"
+		if managerID != 0 {
+			reason = fmt.Sprintf("no fund of %s above review score", managerName)
+		}
+		return &fundMatch{reason: reason + describeCandidates(candidates)}, nil
+	}
+
+	// Candidates too close to the best to tell apart tie with it.
+	best := candidates[0]
+	tied := 0
+	for _, candidate := range candidates {
+		if best.Score-candidate.Score >= acceptMargin {
+			break
+		}
+		tied++
+	}
+
+	if best.Score >= r.acceptScore && tied == 1 {
+		return &fundMatch{trustNo: best.TrustNo, matchedName: best.Name}, nil
+	}
+
+	// Every tied candidate is proposed, so the reviewer chooses between them.
+	for _, candidate := range candidates[:tied] {
+		if err := r.db.ProposeFundNameAliasContext(ctx, fundName, candidate.TrustNo, database.AliasSourceFuzzy); err != nil {
+			return nil, fmt.Errorf("error proposing alias for %s: %w", fundName, err)
+		}
+	}
+
+	if best.Score < r.acceptScore {
+		return &fundMatch{reason: "sent to review" + describeCandidates(candidates)}, nil
+	}
+
+	return &fundMatch{reason: "ambiguous, sent to review" + describeCandidates(candidates)}, nil
+}
+
+// belongsTo reports whether trustNo is managed by managerID. Unknown
+// managers and funds we have no manager for are never excluded.
+func (r *fundResolver) belongsTo(trustNo int, managerID int) bool {
+	fundManager, ok := r.fundManagers[trustNo]
+	return managerID == 0 || !ok || fundManager == managerID
+}
+
+func describeCandidates(candidates []matcher.Match) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	described := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		described = append(described, fmt.Sprintf("%s [%d] %.2f", candidate.Name, candidate.TrustNo, candidate.Score))
+	}
+
+	return ": " + strings.Join(described, ", ")
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+func TestResolveSendsTiesToReview(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemoryStore()
+
+	managers := []*models.CISManager{
+		{ID: 1, Name: "Allan Gray Unit Trust Management (RF) (Pty) Ltd"},
+		{ID: 2, Name: "Coronation Management Company (RF) (Pty) Ltd"},
+	}
+	if err := db.SaveCISManagersContext(ctx, managers); err != nil {
+		t.Fatal(err)
+	}
+
+	funds := []*models.Fund{
+		{TrustNo: 10, Name: "Allan Gray Balanced Plus Fund", ManagerID: 1},
+		{TrustNo: 20, Name: "Coronation Balanced Plus Fund", ManagerID: 2},
+	}
+	if err := db.SaveFundsContext(ctx, funds); err != nil {
+		t.Fatal(err)
+	}
+
+	resolver, err := newFundResolver(ctx, db, 0.9, 0.7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Listed without a brand under a manager we do not know, the name fits
+	// both funds equally.
+	match, err := resolver.resolve(ctx, "Balanced Plus Fund", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.trustNo != 0 {
+		t.Errorf("ambiguous name matched fund %d, want it sent to review", match.trustNo)
+	}
+
+	aliases, err := db.GetFundNameAliasesContext(ctx, "Balanced Plus Fund")
+	if err != nil {
+		t.Fatal(err)
+	}
+	proposed := make(map[int]bool)
+	for _, alias := range aliases {
+		if alias.Status == database.AliasStatusProposed {
+			proposed[alias.TrustNo] = true
+		}
+	}
+	if !proposed[10] || !proposed[20] {
+		t.Errorf("proposed %v, want both funds for review", proposed)
+	}
+
+	// Under its manager the same name is clear.
+	match, err = resolver.resolve(ctx, "Balanced Plus Fund", "Coronation Management Company (RF) (Pty) Ltd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.trustNo != 20 {
+		t.Errorf("listed under Coronation matched %d (%s), want 20", match.trustNo, match.reason)
+	}
+
+	// A clear match is accepted without leaving an alias to review.
+	match, err = resolver.resolve(ctx, "Allan Gray Balanced Plus", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.trustNo != 10 {
+		t.Errorf("Allan Gray Balanced Plus matched %d (%s), want 10", match.trustNo, match.reason)
+	}
+
+	aliases, err = db.GetFundNameAliasesContext(ctx, "Allan Gray Balanced Plus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aliases) != 0 {
+		t.Errorf("auto-accepted match proposed %+v, want no aliases", aliases)
+	}
+}
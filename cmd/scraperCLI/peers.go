@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/analytics"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+func peers(ctx context.Context, db *database.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: peers snapshot [-as-of date] | show <fund class id> | history [-metric name] <fund class id>")
+	}
+
+	switch args[0] {
+	case "snapshot":
+		return snapshotPeers(ctx, db, args[1:])
+	case "show":
+		return showPeers(ctx, db, args[1:])
+	case "history":
+		return peerHistory(ctx, db, args[1:])
+	default:
+		return fmt.Errorf("unknown peers command %q", args[0])
+	}
+}
+
+// snapshotPeers ranks every fund class in its category and saves the day's
+// snapshot, replacing one already saved for the date. Run it daily after
+// -prices.
+func snapshotPeers(ctx context.Context, db *database.DB, args []string) error {
+	flags := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	asOf := flags.String("as-of", time.Now().Format("2006-01-02"), "Date (YYYY-MM-DD) to rank returns up to")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	date, err := models.ParseDate(*asOf)
+	if err != nil {
+		return err
+	}
+
+	rankings, err := analytics.Calculator{}.PeerSnapshotContext(ctx, db, date)
+	if err != nil {
+		return err
+	}
+
+	if err := db.SavePeerRankingsContext(ctx, date, rankings); err != nil {
+		return err
+	}
+
+	categories := make(map[string]bool)
+	for _, r := range rankings {
+		categories[r.Category] = true
+	}
+
+	log.Printf("Saved %d peer rankings in %d categories for %s\n", len(rankings), len(categories), date)
+	return nil
+}
+
+// showPeers prints the latest rankings of a fund class next to its quartile
+// in the snapshot before.
+func showPeers(ctx context.Context, db database.ReadStore, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: peers show <fund class id>")
+	}
+
+	fundClassID, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("error invalid fund class id: %s : %w", args[0], err)
+	}
+
+	rankings, err := db.GetLatestPeerRankingsContext(ctx, fundClassID)
+	if err != nil {
+		return err
+	}
+
+	if len(rankings) == 0 {
+		return fmt.Errorf("fund class %d has not been ranked, run scraperCLI peers snapshot", fundClassID)
+	}
+
+	fmt.Printf("Fund class %d in %s on %s\n", fundClassID, rankings[0].Category, rankings[0].SnapshotDate)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METRIC\tVALUE\tRANK\tQUARTILE\tPERCENTILE\tPREVIOUS QUARTILE")
+	for _, r := range rankings {
+		dayBefore := r.SnapshotDate.AddDays(-1)
+		history, _, err := db.ListPeerRankingsContext(ctx, fundClassID, r.Metric, nil, &dayBefore, database.Page{})
+		if err != nil {
+			return err
+		}
+
+		previous := "-"
+		if len(history) > 0 {
+			last := history[len(history)-1]
+			previous = fmt.Sprintf("%d (%s)", last.Quartile, last.SnapshotDate)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%d of %d\t%d\t%d\t%s\n", r.Metric, metricValue(r), r.Rank, r.Peers, r.Quartile, r.Percentile, previous)
+	}
+
+	return w.Flush()
+}
+
+func peerHistory(ctx context.Context, db database.ReadStore, args []string) error {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	metric := flags.String("metric", analytics.ReturnMetric("3Y"), "Metric to show the rankings of")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: peers history [-metric name] <fund class id>")
+	}
+
+	fundClassID, err := strconv.Atoi(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("error invalid fund class id: %s : %w", flags.Arg(0), err)
+	}
+
+	history, _, err := db.ListPeerRankingsContext(ctx, fundClassID, *metric, nil, nil, database.Page{})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tVALUE\tRANK\tQUARTILE\tPERCENTILE")
+	for _, r := range history {
+		fmt.Fprintf(w, "%s\t%s\t%d of %d\t%d\t%d\n", r.SnapshotDate, metricValue(r), r.Rank, r.Peers, r.Quartile, r.Percentile)
+	}
+
+	return w.Flush()
+}
+
+// metricValue prints returns, which are fractions, and the TIC, which is
+// stored as a percentage, both as percentages.
+func metricValue(r *models.PeerRanking) string {
+	if r.Metric == analytics.MetricTIC {
+		return fmt.Sprintf("%.2f%%", r.Value)
+	}
+	return percent(&r.Value)
+}
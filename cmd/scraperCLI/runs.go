@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/scraper"
+)
+
+// runLedger records one scrape mode's run and its per-item outcomes in the
+// scrape_runs tables. It is safe for concurrent use by the fund workers.
+type runLedger struct {
+	db        database.Store
+	mu        sync.Mutex
+	run       *models.ScrapeRun
+	completed map[string]bool
+	// maxDropped is the share of the run's scraped rows, from 0 to 1, the
+	// parsers may drop before the finished run is marked failed.
+	maxDropped float64
+}
+
+func startRun(ctx context.Context, db database.Store, mode string, maxDropped float64, resumeFrom *models.ScrapeRun) (*runLedger, error) {
+	run := &models.ScrapeRun{
+		Mode:      mode,
+		Status:    database.RunStatusRunning,
+		StartedAt: time.Now().UTC(),
+	}
+
+	var completed []string
+	if resumeFrom != nil {
+		run.ResumedFrom = &resumeFrom.ID
+
+		var err error
+		completed, err = db.GetScrapeRunCheckpointsContext(ctx, resumeFrom.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := db.CreateScrapeRunContext(ctx, run); err != nil {
+		return nil, fmt.Errorf("error recording scrape run: %w", err)
+	}
+
+	ledger := &runLedger{db: db, run: run, completed: make(map[string]bool, len(completed)), maxDropped: maxDropped}
+
+	// Carry the previous run's checkpoints forward so a chain of resumed runs
+	// never repeats finished work.
+	for _, itemKey := range completed {
+		if err := db.SaveScrapeRunCheckpointContext(ctx, run.ID, itemKey); err != nil {
+			return nil, fmt.Errorf("error carrying checkpoint %s forward: %w", itemKey, err)
+		}
+		ledger.completed[itemKey] = true
+	}
+
+	if resumeFrom != nil {
+		log.Printf("Started %s run %d resuming run %d with %d completed items\n", mode, run.ID, resumeFrom.ID, len(completed))
+	} else {
+		log.Printf("Started %s run %d\n", mode, run.ID)
+	}
+	return ledger, nil
+}
+
+// record adds an item outcome and lets update adjust the run counters.
+func (l *runLedger) record(item string, status string, message string, update func(run *models.ScrapeRun)) {
+	l.mu.Lock()
+	if update != nil {
+		update(l.run)
+	}
+	runID := l.run.ID
+	l.mu.Unlock()
+
+	runItem := &models.ScrapeRunItem{RunID: runID, Item: item, Status: status}
+	if message != "" {
+		runItem.Message = &message
+	}
+
+	// Items are written even after cancellation so the ledger shows what completed.
+	if err := l.db.SaveScrapeRunItemContext(context.Background(), runItem); err != nil {
+		log.Printf("Error recording run item %s: %v\n", item, err)
+	}
+}
+
+// diagnose records the rows a parser dropped or only partly read under item
+// and adds them to the run's row counts.
+func (l *runLedger) diagnose(item string, diagnostics scraper.Diagnostics) {
+	for _, warning := range diagnostics.Warnings {
+		name := item
+		if warning.Item != "" {
+			name = warning.Item
+		}
+
+		if warning.Dropped {
+			l.record(name, database.ItemStatusDropped, warning.String(), nil)
+			continue
+		}
+		l.record(name, database.ItemStatusWarning, warning.String(), func(run *models.ScrapeRun) {
+			run.Warnings++
+		})
+	}
+
+	if len(diagnostics.Warnings) > 0 {
+		log.Printf("%s: %s\n", item, diagnostics.Summary())
+	}
+
+	l.mu.Lock()
+	l.run.RowsParsed += diagnostics.Parsed
+	l.run.RowsDropped += diagnostics.Dropped()
+	l.mu.Unlock()
+}
+
+// checkDropped returns an error when the share of dropped rows over the
+// whole run passes maxDropped. It is only meaningful once the run is done,
+// since a single small page early on can drop a large share of a few rows.
+func (l *runLedger) checkDropped() error {
+	l.mu.Lock()
+	parsed, dropped := l.run.RowsParsed, l.run.RowsDropped
+	l.mu.Unlock()
+
+	if total := parsed + dropped; total > 0 && float64(dropped)/float64(total) > l.maxDropped {
+		return fmt.Errorf("dropped %d of %d scraped rows, more than the %g allowed by -max-dropped", dropped, total, l.maxDropped)
+	}
+	return nil
+}
+
+// isCompleted reports whether itemKey was checkpointed by the run being resumed.
+func (l *runLedger) isCompleted(itemKey string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.completed[itemKey]
+}
+
+// checkpoint marks itemKey as finished so a resumed run can skip it.
+func (l *runLedger) checkpoint(itemKey string) {
+	l.mu.Lock()
+	l.completed[itemKey] = true
+	runID := l.run.ID
+	l.mu.Unlock()
+
+	if err := l.db.SaveScrapeRunCheckpointContext(context.Background(), runID, itemKey); err != nil {
+		log.Printf("Error saving checkpoint %s: %v\n", itemKey, err)
+	}
+}
+
+func (l *runLedger) update(update func(run *models.ScrapeRun)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	update(l.run)
+}
+
+// finish stores the final counters and derives the status from err.
+func (l *runLedger) finish(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	finishedAt := time.Now().UTC()
+	l.run.FinishedAt = &finishedAt
+
+	switch {
+	case err == nil:
+		l.run.Status = database.RunStatusSucceeded
+	case errors.Is(err, context.Canceled):
+		l.run.Status = database.RunStatusCancelled
+	default:
+		l.run.Status = database.RunStatusFailed
+	}
+
+	if err != nil {
+		message := err.Error()
+		l.run.Error = &message
+	}
+
+	if err := l.db.FinishScrapeRunContext(context.Background(), l.run); err != nil {
+		log.Printf("Error recording end of run %d: %v\n", l.run.ID, err)
+	}
+}
+
+// withRun wraps one scrape mode in a ledger entry that fails when more than
+// maxDropped of the run's scraped rows were dropped.
+func withRun(ctx context.Context, db database.Store, mode string, maxDropped float64, scrape func(ledger *runLedger) error) error {
+	return withResumableRun(ctx, db, mode, maxDropped, false, scrape)
+}
+
+// withResumableRun is withRun that, when resume is set, continues the latest
+// unfinished run of mode from its checkpoints.
+func withResumableRun(ctx context.Context, db database.Store, mode string, maxDropped float64, resume bool, scrape func(ledger *runLedger) error) error {
+	var resumeFrom *models.ScrapeRun
+	if resume {
+		previous, err := db.GetLatestScrapeRunContext(ctx, mode)
+		if err != nil {
+			return err
+		}
+
+		if previous == nil || previous.Status == database.RunStatusSucceeded {
+			log.Printf("No unfinished %s run to resume\n", mode)
+			return nil
+		}
+		resumeFrom = previous
+	}
+
+	ledger, err := startRun(ctx, db, mode, maxDropped, resumeFrom)
+	if err != nil {
+		return err
+	}
+
+	err = scrape(ledger)
+	if err == nil {
+		err = ledger.checkDropped()
+	}
+	ledger.finish(err)
+	return err
+}
+
+func runs(ctx context.Context, db database.Store, args []string) error {
+	if len(args) == 0 {
+		return listRuns(ctx, db)
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("error invalid run id: %s : %w", args[0], err)
+	}
+
+	return showRun(ctx, db, id)
+}
+
+func listRuns(ctx context.Context, db database.Store) error {
+	scrapeRuns, err := db.ListScrapeRunsContext(ctx, 20)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tMODE\tSTATUS\tSTARTED\tDURATION\tMATCHED\tUNMATCHED\tMANAGERS\tFUNDS\tPRICES\tCOSTS\tDROPPED\tWARNINGS")
+	for _, run := range scrapeRuns {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n",
+			run.ID, run.Mode, run.Status, run.StartedAt.Local().Format("2006-01-02 15:04:05"), runDuration(run),
+			run.Matched, run.Unmatched, run.SavedManagers, run.SavedFunds, run.SavedPrices, run.SavedCosts, run.RowsDropped, run.Warnings)
+	}
+
+	return w.Flush()
+}
+
+func showRun(ctx context.Context, db database.Store, id int) error {
+	run, err := db.GetScrapeRunContext(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if run == nil {
+		return fmt.Errorf("no scrape run with id %d", id)
+	}
+
+	items, err := db.GetScrapeRunItemsContext(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Run %d (%s): %s\n", run.ID, run.Mode, run.Status)
+	fmt.Printf("Started:  %s\n", run.StartedAt.Local().Format("2006-01-02 15:04:05"))
+	fmt.Printf("Duration: %s\n", runDuration(run))
+	if run.ResumedFrom != nil {
+		fmt.Printf("Resumed:  run %d\n", *run.ResumedFrom)
+	}
+	fmt.Printf("Matched %d, unmatched %d, saved %d managers, %d funds, %d prices, %d costs\n",
+		run.Matched, run.Unmatched, run.SavedManagers, run.SavedFunds, run.SavedPrices, run.SavedCosts)
+	fmt.Printf("Rows:     %d parsed, %d dropped, %d cells unreadable\n", run.RowsParsed, run.RowsDropped, run.Warnings)
+
+	if run.Error != nil {
+		fmt.Printf("Error:    %s\n", *run.Error)
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tITEM\tMESSAGE")
+	for _, item := range items {
+		message := ""
+		if item.Message != nil {
+			message = *item.Message
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", item.Status, item.Item, message)
+	}
+
+	return w.Flush()
+}
+
+func runDuration(run *models.ScrapeRun) string {
+	if run.FinishedAt == nil {
+		return "-"
+	}
+	return run.FinishedAt.Sub(run.StartedAt).Round(time.Second).String()
+}
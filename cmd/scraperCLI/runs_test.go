@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/scraper"
+)
+
+func TestDiagnoseRecordsRowOutcomes(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemoryStore()
+
+	ledger, err := startRun(ctx, db, "prices", 0.1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ledger.diagnose("fund 1261", scraper.Diagnostics{
+		Parsed: 17,
+		Warnings: []scraper.RowWarning{
+			{Row: 4, Field: "Date", Value: "Totals", Message: "unrecognised format", Dropped: true},
+			{Row: 7, Field: "NAV", Value: "1 204.33", Message: "invalid decimal", Item: "Coronation Industrial Fund"},
+		},
+	})
+	if err := ledger.checkDropped(); err != nil {
+		t.Fatalf("1 of 18 rows dropped: %v", err)
+	}
+
+	ledger.diagnose("fund 1262", scraper.Diagnostics{
+		Warnings: []scraper.RowWarning{{Row: 2, Field: "Date", Message: "row has no date", Dropped: true}},
+	})
+	if err := ledger.checkDropped(); err == nil {
+		t.Fatal("2 of 19 rows dropped with -max-dropped=0.1 succeeded, want an error")
+	}
+
+	run := ledger.run
+	if run.RowsParsed != 17 || run.RowsDropped != 2 || run.Warnings != 1 {
+		t.Errorf("run counted %d parsed, %d dropped, %d warnings, want 17, 2, 1", run.RowsParsed, run.RowsDropped, run.Warnings)
+	}
+
+	items, err := db.GetScrapeRunItemsContext(ctx, run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var statuses []string
+	for _, item := range items {
+		statuses = append(statuses, item.Item+" "+item.Status)
+	}
+	want := []string{"fund 1261 dropped", "Coronation Industrial Fund warning", "fund 1262 dropped"}
+	if len(statuses) != len(want) {
+		t.Fatalf("recorded %v, want %v", statuses, want)
+	}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Errorf("item %d = %q, want %q", i, statuses[i], want[i])
+		}
+	}
+}
+
+func TestMaxDroppedAppliesToWholeRun(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemoryStore()
+
+	pages := []scraper.Diagnostics{
+		// The first fund has a three row table, one of which is dropped.
+		{Parsed: 2, Warnings: []scraper.RowWarning{{Row: 1, Field: "Date", Message: "row has no date", Dropped: true}}},
+		{Parsed: 40},
+		{Parsed: 38},
+	}
+
+	scraped := 0
+	err := withRun(ctx, db, "backfill", 0.05, func(ledger *runLedger) error {
+		for i, page := range pages {
+			ledger.diagnose(fmt.Sprintf("fund %d", i), page)
+			scraped++
+		}
+		return nil
+	})
+	if err != nil || scraped != len(pages) {
+		t.Fatalf("1 of 81 rows dropped with -max-dropped=0.05: scraped %d pages, err %v", scraped, err)
+	}
+
+	run, err := db.GetLatestScrapeRunContext(ctx, "backfill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != database.RunStatusSucceeded || run.RowsParsed != 80 || run.RowsDropped != 1 {
+		t.Errorf("run %s with %d parsed, %d dropped, want succeeded with 80 and 1", run.Status, run.RowsParsed, run.RowsDropped)
+	}
+
+	err = withRun(ctx, db, "backfill", 0.005, func(ledger *runLedger) error {
+		for i, page := range pages {
+			ledger.diagnose(fmt.Sprintf("fund %d", i), page)
+		}
+		return nil
+	})
+	if err == nil {
+		t.Fatal("1 of 81 rows dropped with -max-dropped=0.005 succeeded, want an error")
+	}
+
+	run, err = db.GetLatestScrapeRunContext(ctx, "backfill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != database.RunStatusFailed || run.Error == nil {
+		t.Errorf("run %s, want failed with the -max-dropped error", run.Status)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+// screen prints the fund classes that pass a screener filter, such as
+//
+//	scraperCLI screen -sort tic 'category = "South African - Equity - General" and tic < 1.5'
+func screen(ctx context.Context, db database.ReadStore, args []string) error {
+	flags := flag.NewFlagSet("screen", flag.ContinueOnError)
+	sort := flags.String("sort", "", "Field to sort by, prefixed with - to sort descending")
+	limit := flags.Int("limit", 50, "Maximum number of fund classes to print, 0 prints all")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 1 {
+		return fmt.Errorf("usage: screen [-sort field] [-limit n] \"<filter>\"")
+	}
+
+	summaries, total, err := db.ScreenFundClassesContext(ctx, database.Screen{Filter: flags.Arg(0), Sort: *sort}, database.Page{Limit: *limit})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TRUST NO\tFUND\tCLASS\tMANAGER\tCATEGORY\tMARKET\tADD FEE\tMAX INIT\tTER\tTC\tTIC\tCOSTS DATE")
+	for _, s := range summaries {
+		costsDate := ""
+		if s.CostsDate != nil {
+			costsDate = s.CostsDate.String()
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%t\t%s\t%s\t%s\t%s\t%s\n",
+			s.TrustNo, s.FundName, s.ClassName, s.ManagerName, s.Category, s.TargetMarket, s.AddFee,
+			percentage(s.MaxInitFee), percentage(s.TER), percentage(s.TC), percentage(s.TIC), costsDate)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("%d of %d fund classes\n", len(summaries), total)
+	return nil
+}
+
+func percentage(d *models.Decimal) string {
+	if d == nil {
+		return "-"
+	}
+	return d.String() + "%"
+}
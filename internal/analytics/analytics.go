@@ -0,0 +1,261 @@
+// Package analytics computes returns and risk measures from the NAV history
+// in fund_class_prices.
+//
+// Returns are price returns: the ASISA NAVs are not adjusted for
+// distributions, so income funds understate their total return. Periods are
+// measured between actual prices. A horizon starting on a weekend or public
+// holiday is measured from the last price before it, and each return between
+// consecutive prices spans however many calendar days lie between them, so
+// gaps in the series widen a return instead of breaking the calculation.
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+// DefaultMaxGapDays covers a weekend next to a public holiday, and the
+// Easter and year end breaks.
+const DefaultMaxGapDays = 7
+
+const daysPerYear = 365.25
+
+// ErrNoPrices is returned for a fund class without a usable price.
+var ErrNoPrices = errors.New("no prices")
+
+// Calculator computes Reports. The zero value measures the standard horizons
+// against a risk-free rate of 0.
+type Calculator struct {
+	// RiskFreeRate is the annual rate Sharpe and Sortino measure excess
+	// returns against, 0.08 for 8%.
+	RiskFreeRate float64
+	// Horizons defaults to StandardHorizons.
+	Horizons []Horizon
+	// MaxGapDays is how far before a horizon's start the base price may lie
+	// before the series is considered too short for the horizon. It defaults
+	// to DefaultMaxGapDays.
+	MaxGapDays int
+}
+
+// Report is the analytics of one fund class as of its latest price.
+type Report struct {
+	FundClassID  int
+	AsOf         models.Date
+	NAV          float64
+	RiskFreeRate float64
+	Periods      []Period
+}
+
+// Period is one horizon of a Report.
+type Period struct {
+	Horizon string
+	Start   models.Date
+	End     models.Date
+	// Stats is nil when the price series does not reach back to Start.
+	Stats *Stats
+}
+
+// Stats are the measures over a Period. Returns, volatility and drawdown are
+// fractions, 0.05 for 5%.
+type Stats struct {
+	// BaseDate is the date of the price the period is measured from, on or
+	// up to MaxGapDays before the period's start.
+	BaseDate models.Date
+	BaseNAV  float64
+	// Observations counts the prices in the period, the base price included.
+	Observations     int
+	CumulativeReturn float64
+	// AnnualisedReturn is nil for periods shorter than a year, which are
+	// not annualised.
+	AnnualisedReturn *float64
+	// Volatility is the annualised standard deviation of the returns between
+	// consecutive prices, nil with fewer than two of them.
+	Volatility *float64
+	// MaxDrawdown is the largest fall from a peak, as a negative fraction or 0.
+	MaxDrawdown       float64
+	MaxDrawdownPeak   *models.Date
+	MaxDrawdownTrough *models.Date
+	// Sharpe and Sortino are nil when volatility or downside deviation is 0.
+	Sharpe  *float64
+	Sortino *float64
+	// LongestGapDays is the most calendar days between consecutive prices.
+	LongestGapDays int
+}
+
+type point struct {
+	date models.Date
+	nav  float64
+}
+
+// FundClassContext reports on the prices of a fund class up to asOf, or up to
+// its latest price when asOf is nil.
+func (c Calculator) FundClassContext(ctx context.Context, store database.ReadStore, fundClassID int, asOf *models.Date) (*Report, error) {
+	prices, _, err := store.ListFundClassPricesContext(ctx, fundClassID, nil, asOf, database.Page{})
+	if err != nil {
+		return nil, fmt.Errorf("error loading prices of fund class %d: %w", fundClassID, err)
+	}
+
+	report, err := c.Analyse(prices)
+	if err != nil {
+		return nil, fmt.Errorf("error analysing fund class %d: %w", fundClassID, err)
+	}
+	report.FundClassID = fundClassID
+
+	return report, nil
+}
+
+// Analyse reports on prices as of the latest of them. Prices without a
+// positive NAV are ignored, and prices may be in any order.
+func (c Calculator) Analyse(prices []*models.FundClassPrice) (*Report, error) {
+	series := make([]point, 0, len(prices))
+	for _, p := range prices {
+		if p.PriceDate == nil || p.NAV == nil {
+			continue
+		}
+		if nav := p.NAV.Float64(); nav > 0 {
+			series = append(series, point{date: *p.PriceDate, nav: nav})
+		}
+	}
+
+	if len(series) == 0 {
+		return nil, ErrNoPrices
+	}
+
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].date.Before(series[j].date)
+	})
+
+	last := series[len(series)-1]
+	report := &Report{AsOf: last.date, NAV: last.nav, RiskFreeRate: c.RiskFreeRate}
+
+	for _, h := range c.horizons() {
+		period := Period{Horizon: h.Name, Start: h.Start(last.date), End: last.date}
+
+		// The base is the last price on or before the start.
+		base := sort.Search(len(series), func(i int) bool {
+			return series[i].date.After(period.Start)
+		}) - 1
+
+		if base >= 0 && days(series[base].date, period.Start) <= c.maxGapDays() && base < len(series)-1 {
+			annualise := !period.Start.After(monthsBefore(12)(last.date))
+			period.Stats = c.stats(series[base:], annualise)
+		}
+
+		report.Periods = append(report.Periods, period)
+	}
+
+	return report, nil
+}
+
+func (c Calculator) horizons() []Horizon {
+	if c.Horizons == nil {
+		return StandardHorizons
+	}
+	return c.Horizons
+}
+
+func (c Calculator) maxGapDays() int {
+	if c.MaxGapDays == 0 {
+		return DefaultMaxGapDays
+	}
+	return c.MaxGapDays
+}
+
+// stats measures a series of at least two prices.
+func (c Calculator) stats(series []point, annualise bool) *Stats {
+	first, last := series[0], series[len(series)-1]
+	years := float64(days(first.date, last.date)) / daysPerYear
+
+	s := &Stats{
+		BaseDate:         first.date,
+		BaseNAV:          first.nav,
+		Observations:     len(series),
+		CumulativeReturn: last.nav/first.nav - 1,
+	}
+
+	if annualise {
+		s.AnnualisedReturn = ptr(math.Pow(1+s.CumulativeReturn, 1/years) - 1)
+	}
+
+	returns := make([]float64, 0, len(series)-1)
+	excess := make([]float64, 0, len(series)-1)
+	peak := first
+	for i := 1; i < len(series); i++ {
+		prev, cur := series[i-1], series[i]
+		gap := days(prev.date, cur.date)
+		s.LongestGapDays = max(s.LongestGapDays, gap)
+
+		r := cur.nav/prev.nav - 1
+		returns = append(returns, r)
+		// The risk-free return accrues over the same calendar days.
+		excess = append(excess, r-(math.Pow(1+c.RiskFreeRate, float64(gap)/daysPerYear)-1))
+
+		if cur.nav > peak.nav {
+			peak = cur
+		} else if drawdown := cur.nav/peak.nav - 1; drawdown < s.MaxDrawdown {
+			peakDate, troughDate := peak.date, cur.date
+			s.MaxDrawdown = drawdown
+			s.MaxDrawdownPeak, s.MaxDrawdownTrough = &peakDate, &troughDate
+		}
+	}
+
+	if len(returns) < 2 {
+		return s
+	}
+
+	// Scale by the observed number of prices per year, which is about 250
+	// for a daily priced fund and fewer for one with gaps.
+	perYear := float64(len(returns)) / years
+	volatility := stddev(returns) * math.Sqrt(perYear)
+	s.Volatility = &volatility
+
+	meanExcess := mean(excess) * perYear
+	if volatility > 0 {
+		s.Sharpe = ptr(meanExcess / volatility)
+	}
+
+	var downside float64
+	for _, e := range excess {
+		if e < 0 {
+			downside += e * e
+		}
+	}
+	if downside > 0 {
+		s.Sortino = ptr(meanExcess / (math.Sqrt(downside/float64(len(excess))) * math.Sqrt(perYear)))
+	}
+
+	return s
+}
+
+// days counts the calendar days from a to b.
+func days(a, b models.Date) int {
+	return int(math.Round(b.Time().Sub(a.Time()).Hours() / 24))
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// stddev is the sample standard deviation.
+func stddev(values []float64) float64 {
+	m := mean(values)
+	var sum float64
+	for _, v := range values {
+		sum += (v - m) * (v - m)
+	}
+	return math.Sqrt(sum / float64(len(values)-1))
+}
+
+func ptr(f float64) *float64 {
+	return &f
+}
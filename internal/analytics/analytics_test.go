@@ -0,0 +1,210 @@
+package analytics
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+func date(s string) models.Date {
+	d, err := models.ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func price(d models.Date, nav float64) *models.FundClassPrice {
+	value, err := models.DecimalFromFloat(nav)
+	if err != nil {
+		panic(err)
+	}
+	return &models.FundClassPrice{PriceDate: &d, NAV: &value}
+}
+
+// weekdays prices every weekday from start to end at a NAV that grows by
+// growth a calendar day, so returns only depend on the dates measured.
+func weekdays(start, end string, growth float64) []*models.FundClassPrice {
+	var prices []*models.FundClassPrice
+	for d := date(start); !d.After(date(end)); d = d.AddDays(1) {
+		if wd := d.Time().Weekday(); wd == time.Saturday || wd == time.Sunday {
+			continue
+		}
+		prices = append(prices, price(d, 100*math.Pow(1+growth, float64(days(date(start), d)))))
+	}
+	return prices
+}
+
+func near(got, want float64) bool {
+	return math.Abs(got-want) < 1e-6
+}
+
+func period(t *testing.T, report *Report, horizon string) Period {
+	t.Helper()
+	for _, p := range report.Periods {
+		if p.Horizon == horizon {
+			return p
+		}
+	}
+	t.Fatalf("report has no %s period", horizon)
+	return Period{}
+}
+
+func TestHorizonStarts(t *testing.T) {
+	tests := []struct {
+		horizon int
+		end     string
+		want    string
+	}{
+		{0, "2024-10-17", "2024-09-17"},
+		{0, "2024-03-31", "2024-02-29"},
+		{1, "2024-05-31", "2024-02-29"},
+		{2, "2024-10-17", "2023-12-31"},
+		{3, "2024-02-29", "2023-02-28"},
+		{4, "2024-10-17", "2021-10-17"},
+		{5, "2024-10-17", "2019-10-17"},
+	}
+
+	for _, tt := range tests {
+		h := StandardHorizons[tt.horizon]
+		if got := h.Start(date(tt.end)); got != date(tt.want) {
+			t.Errorf("%s ending %s starts %s, want %s", h.Name, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyseReturnsOverHorizons(t *testing.T) {
+	// Three and a half years of weekday prices, ending on Thursday 17 October.
+	prices := weekdays("2021-04-01", "2024-10-17", 0.0002)
+
+	report, err := Calculator{}.Analyse(prices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.AsOf != date("2024-10-17") || len(report.Periods) != len(StandardHorizons) {
+		t.Fatalf("report as of %s with %d periods, want 2024-10-17 with %d", report.AsOf, len(report.Periods), len(StandardHorizons))
+	}
+
+	// 17 September 2024 is a Tuesday, so 1M is measured from it.
+	oneMonth := period(t, report, "1M").Stats
+	if oneMonth.BaseDate != date("2024-09-17") || !near(oneMonth.CumulativeReturn, math.Pow(1.0002, 30)-1) {
+		t.Errorf("1M = %s %v, want from 2024-09-17 over 30 days", oneMonth.BaseDate, oneMonth.CumulativeReturn)
+	}
+	if oneMonth.AnnualisedReturn != nil {
+		t.Errorf("1M annualised = %v, want nil", *oneMonth.AnnualisedReturn)
+	}
+
+	// 31 December 2023 is a Sunday, so YTD is measured from Friday the 29th.
+	ytd := period(t, report, "YTD").Stats
+	if ytd.BaseDate != date("2023-12-29") || !near(ytd.CumulativeReturn, math.Pow(1.0002, 293)-1) {
+		t.Errorf("YTD = %s %v, want from 2023-12-29 over 293 days", ytd.BaseDate, ytd.CumulativeReturn)
+	}
+
+	// 17 October 2021 is a Sunday.
+	threeYears := period(t, report, "3Y").Stats
+	if threeYears.BaseDate != date("2021-10-15") {
+		t.Errorf("3Y base = %s, want 2021-10-15", threeYears.BaseDate)
+	}
+	if threeYears.AnnualisedReturn == nil || !near(*threeYears.AnnualisedReturn, math.Pow(1.0002, 365.25)-1) {
+		t.Errorf("3Y annualised = %v, want the daily growth over 365.25 days", threeYears.AnnualisedReturn)
+	}
+	if threeYears.MaxDrawdown != 0 || threeYears.MaxDrawdownPeak != nil || threeYears.LongestGapDays != 3 {
+		t.Errorf("3Y drawdown %v gap %d, want no drawdown and weekend gaps", threeYears.MaxDrawdown, threeYears.LongestGapDays)
+	}
+
+	if fiveYears := period(t, report, "5Y"); fiveYears.Stats != nil || fiveYears.Start != date("2019-10-17") {
+		t.Errorf("5Y = %+v, want no stats for a series starting in 2021", fiveYears)
+	}
+}
+
+func TestAnalyseMaxDrawdown(t *testing.T) {
+	start := date("2024-01-01")
+	var prices []*models.FundClassPrice
+	for i, nav := range []float64{100, 110, 99, 105, 120, 90, 100} {
+		prices = append(prices, price(start.AddDays(i), nav))
+	}
+
+	report, err := Calculator{Horizons: []Horizon{{Name: "all", Start: func(models.Date) models.Date { return start }}}}.Analyse(prices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := report.Periods[0].Stats
+	if !near(stats.MaxDrawdown, -0.25) || *stats.MaxDrawdownPeak != start.AddDays(4) || *stats.MaxDrawdownTrough != start.AddDays(5) {
+		t.Errorf("drawdown %v from %v to %v, want -25%% from 120 to 90", stats.MaxDrawdown, stats.MaxDrawdownPeak, stats.MaxDrawdownTrough)
+	}
+}
+
+func TestAnalyseSharpeAndSortino(t *testing.T) {
+	start := date("2024-01-01")
+	prices := []*models.FundClassPrice{price(start, 100), price(start.AddDays(1), 101), price(start.AddDays(2), 100), price(start.AddDays(3), 102)}
+	all := []Horizon{{Name: "all", Start: func(models.Date) models.Date { return start }}}
+
+	report, err := Calculator{Horizons: all}.Analyse(prices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := report.Periods[0].Stats
+	if !near(*stats.Volatility, 0.2909011356769909) || !near(*stats.Sharpe, 8.411979725519851) || !near(*stats.Sortino, 22.39909763807462) {
+		t.Errorf("volatility %v, Sharpe %v, Sortino %v", *stats.Volatility, *stats.Sharpe, *stats.Sortino)
+	}
+
+	withCash, err := Calculator{Horizons: all, RiskFreeRate: 0.08}.Analyse(prices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sharpe := *withCash.Periods[0].Stats.Sharpe; sharpe >= *stats.Sharpe {
+		t.Errorf("Sharpe against 8%% cash = %v, want below %v", sharpe, *stats.Sharpe)
+	}
+
+	rising, err := Calculator{Horizons: all}.Analyse(weekdays("2024-01-01", "2024-01-31", 0.001))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := rising.Periods[0].Stats; s.Sortino != nil || s.Sharpe == nil {
+		t.Errorf("steady rise Sharpe %v Sortino %v, want a Sharpe and no downside", s.Sharpe, s.Sortino)
+	}
+}
+
+func TestAnalyseHandlesGaps(t *testing.T) {
+	// The site published nothing from mid June to the end of July.
+	prices := append(weekdays("2023-12-01", "2024-06-14", 0.0001), weekdays("2024-08-01", "2024-10-17", 0.0001)...)
+	// Suspended classes print a zero or no NAV, which must not count as a price.
+	prices = append(prices, price(date("2024-10-18"), 0), &models.FundClassPrice{PriceDate: ptrDate(date("2024-10-19"))})
+
+	report, err := Calculator{}.Analyse(prices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.AsOf != date("2024-10-17") {
+		t.Errorf("as of %s, want the last positive NAV on 2024-10-17", report.AsOf)
+	}
+
+	threeMonths := period(t, report, "3M")
+	if threeMonths.Stats != nil {
+		t.Errorf("3M from %s = %+v, want no stats when the base is in a 7 week gap", threeMonths.Start, threeMonths.Stats)
+	}
+
+	ytd := period(t, report, "YTD").Stats
+	if ytd == nil || ytd.LongestGapDays != 48 || ytd.BaseDate != date("2023-12-29") {
+		t.Fatalf("YTD = %+v, want measured from 2023-12-29 across the 48 day gap", ytd)
+	}
+	// The series restarts at 100 after the gap.
+	if want := 100*math.Pow(1.0001, 77)/(100*math.Pow(1.0001, 28)) - 1; !near(ytd.CumulativeReturn, want) {
+		t.Errorf("YTD return = %v", ytd.CumulativeReturn)
+	}
+
+	if _, err := (Calculator{}).Analyse(nil); !errors.Is(err, ErrNoPrices) {
+		t.Errorf("no prices: err = %v, want ErrNoPrices", err)
+	}
+}
+
+func ptrDate(d models.Date) *models.Date {
+	return &d
+}
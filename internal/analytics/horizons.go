@@ -0,0 +1,42 @@
+package analytics
+
+import (
+	"time"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+// Horizon is a period ending on the report date that returns and risk are
+// measured over.
+type Horizon struct {
+	Name string
+	// Start returns the base date of the horizon ending on end. The return
+	// over the horizon is measured from the price on or just before it.
+	Start func(end models.Date) models.Date
+}
+
+// StandardHorizons are the periods fund fact sheets report on.
+var StandardHorizons = []Horizon{
+	{Name: "1M", Start: monthsBefore(1)},
+	{Name: "3M", Start: monthsBefore(3)},
+	{Name: "YTD", Start: yearToDate},
+	{Name: "1Y", Start: monthsBefore(12)},
+	{Name: "3Y", Start: monthsBefore(36)},
+	{Name: "5Y", Start: monthsBefore(60)},
+}
+
+// monthsBefore steps back whole months, clamping to the end of shorter months
+// so 31 March less one month is the last day of February.
+func monthsBefore(months int) func(end models.Date) models.Date {
+	return func(end models.Date) models.Date {
+		first := time.Date(end.Year, end.Month, 1, 0, 0, 0, 0, time.UTC).AddDate(0, -months, 0)
+		lastDay := first.AddDate(0, 1, -1).Day()
+		return models.DateOf(first.AddDate(0, 0, min(end.Day, lastDay)-1))
+	}
+}
+
+// yearToDate is measured from the last day of the previous year, so the
+// return includes the first trading day of the year.
+func yearToDate(end models.Date) models.Date {
+	return models.Date{Year: end.Year - 1, Month: time.December, Day: 31}
+}
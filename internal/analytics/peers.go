@@ -0,0 +1,151 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+// MetricTIC ranks classes by the total investment charge current on the
+// snapshot date.
+const MetricTIC = "tic"
+
+// ReturnMetric names the ranking by cumulative return over a horizon, such
+// as return_3y.
+func ReturnMetric(horizon string) string {
+	return "return_" + strings.ToLower(horizon)
+}
+
+// PeerMetrics returns the metrics PeerSnapshotContext ranks on: the return
+// over each horizon and the TIC.
+func (c Calculator) PeerMetrics() []string {
+	var metrics []string
+	for _, h := range c.horizons() {
+		metrics = append(metrics, ReturnMetric(h.Name))
+	}
+	return append(metrics, MetricTIC)
+}
+
+// PeerSnapshotContext ranks every fund class with a category against the
+// other classes in it, on its returns up to asOf and the TIC current on asOf,
+// so a backdated snapshot does not rank on costs published after it. A class
+// is only ranked on a return when it has prices over the whole horizon and
+// up to within MaxGapDays of asOf, so stale classes do not compete with
+// periods they were not priced for.
+func (c Calculator) PeerSnapshotContext(ctx context.Context, store database.ReadStore, asOf models.Date) ([]*models.PeerRanking, error) {
+	classes, _, err := store.ScreenFundClassesContext(ctx, database.Screen{Filter: "category != ''"}, database.Page{})
+	if err != nil {
+		return nil, err
+	}
+
+	// values holds category -> metric -> fund class id -> value.
+	values := make(map[string]map[string]map[int]float64)
+	add := func(category, metric string, fundClassID int, value float64) {
+		if values[category] == nil {
+			values[category] = make(map[string]map[int]float64)
+		}
+		if values[category][metric] == nil {
+			values[category][metric] = make(map[int]float64)
+		}
+		values[category][metric][fundClassID] = value
+	}
+
+	for _, class := range classes {
+		costs, err := store.GetFundClassCostsAsOfContext(ctx, class.FundClassID, asOf)
+		if err != nil {
+			return nil, err
+		}
+		if costs != nil && costs.TIC != nil {
+			add(class.Category, MetricTIC, class.FundClassID, costs.TIC.Float64())
+		}
+
+		report, err := c.FundClassContext(ctx, store, class.FundClassID, &asOf)
+		if errors.Is(err, ErrNoPrices) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if days(report.AsOf, asOf) > c.maxGapDays() {
+			continue
+		}
+
+		for _, period := range report.Periods {
+			if period.Stats != nil {
+				add(class.Category, ReturnMetric(period.Horizon), class.FundClassID, period.Stats.CumulativeReturn)
+			}
+		}
+	}
+
+	var rankings []*models.PeerRanking
+	for category, metrics := range values {
+		for metric, byClass := range metrics {
+			rankings = append(rankings, RankPeers(category, metric, byClass, metric == MetricTIC)...)
+		}
+	}
+
+	sort.Slice(rankings, func(i, j int) bool {
+		a, b := rankings[i], rankings[j]
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		if a.Metric != b.Metric {
+			return a.Metric < b.Metric
+		}
+		return a.Rank < b.Rank || (a.Rank == b.Rank && a.FundClassID < b.FundClassID)
+	})
+
+	for _, ranking := range rankings {
+		ranking.SnapshotDate = asOf
+	}
+
+	return rankings, nil
+}
+
+// RankPeers ranks the values of one category's classes, highest first or
+// lowest first when lowerIsBetter. Equal values share the better rank.
+// Percentiles run from 1 for the best class to 100 for the worst, and
+// quartile 1 holds percentiles 1 to 25.
+func RankPeers(category, metric string, values map[int]float64, lowerIsBetter bool) []*models.PeerRanking {
+	rankings := make([]*models.PeerRanking, 0, len(values))
+	for fundClassID, value := range values {
+		rankings = append(rankings, &models.PeerRanking{FundClassID: fundClassID, Category: category, Metric: metric, Value: value})
+	}
+
+	better := func(a, b float64) bool {
+		if lowerIsBetter {
+			return a < b
+		}
+		return a > b
+	}
+
+	sort.Slice(rankings, func(i, j int) bool {
+		if rankings[i].Value != rankings[j].Value {
+			return better(rankings[i].Value, rankings[j].Value)
+		}
+		return rankings[i].FundClassID < rankings[j].FundClassID
+	})
+
+	peers := len(rankings)
+	for i, ranking := range rankings {
+		ranking.Rank = i + 1
+		if i > 0 && ranking.Value == rankings[i-1].Value {
+			ranking.Rank = rankings[i-1].Rank
+		}
+		ranking.Peers = peers
+
+		ranking.Percentile = 1
+		if peers > 1 {
+			ranking.Percentile = 1 + int(math.Round(99*float64(ranking.Rank-1)/float64(peers-1)))
+		}
+		ranking.Quartile = (ranking.Percentile-1)/25 + 1
+	}
+
+	return rankings
+}
@@ -0,0 +1,184 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+func TestRankPeers(t *testing.T) {
+	values := map[int]float64{1: 0.10, 2: 0.25, 3: 0.10, 4: -0.05, 5: 0.30}
+
+	got := make(map[int]string)
+	for _, r := range RankPeers("Equity", "return_1y", values, false) {
+		got[r.FundClassID] = fmt.Sprintf("%d/%d q%d p%d", r.Rank, r.Peers, r.Quartile, r.Percentile)
+	}
+
+	want := map[int]string{5: "1/5 q1 p1", 2: "2/5 q2 p26", 1: "3/5 q3 p51", 3: "3/5 q3 p51", 4: "5/5 q4 p100"}
+	for id, w := range want {
+		if got[id] != w {
+			t.Errorf("class %d = %s, want %s", id, got[id], w)
+		}
+	}
+
+	tic := RankPeers("Equity", MetricTIC, map[int]float64{1: 1.2, 2: 0.9}, true)
+	if tic[0].FundClassID != 2 || tic[0].Quartile != 1 || tic[1].Quartile != 4 {
+		t.Errorf("TIC ranks %+v %+v, want the cheaper class first", tic[0], tic[1])
+	}
+
+	alone := RankPeers("Income", MetricTIC, map[int]float64{7: 1}, true)
+	if alone[0].Rank != 1 || alone[0].Percentile != 1 || alone[0].Quartile != 1 {
+		t.Errorf("only class = %+v, want the best of 1", alone[0])
+	}
+}
+
+func TestPeerSnapshot(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+
+	if err := store.SaveCISManagersContext(ctx, []*models.CISManager{{ID: 1, Name: "Manager"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveFundsContext(ctx, []*models.Fund{{TrustNo: 10, Name: "Fund", ManagerID: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tic := func(s string) *models.Decimal {
+		d, _ := models.ParseDecimal(s)
+		return &d
+	}
+
+	for _, c := range []struct {
+		name     string
+		category string
+		tic      *models.Decimal
+		prices   []*models.FundClassPrice
+	}{
+		{"A", "Equity", tic("1.50"), weekdays("2023-12-01", "2024-10-17", 0.0003)},
+		{"B", "Equity", tic("0.90"), weekdays("2023-12-01", "2024-10-17", 0.0001)},
+		// C stopped being priced in August, so it is only ranked on its TIC.
+		{"C", "Equity", tic("1.10"), weekdays("2023-12-01", "2024-08-30", 0.0005)},
+		// D has too short a history for YTD or 3M.
+		{"D", "Equity", nil, weekdays("2024-09-02", "2024-10-17", 0.0002)},
+		{"E", "Income", tic("0.50"), weekdays("2023-12-01", "2024-10-17", 0.0001)},
+		{"F", "", tic("0.10"), weekdays("2023-12-01", "2024-10-17", 0.0001)},
+	} {
+		class := &models.FundClass{FundID: 10, ClassName: c.name, Category: c.category}
+		if err := store.SaveFundClassContext(ctx, class); err != nil {
+			t.Fatal(err)
+		}
+		if c.tic != nil {
+			if err := store.SaveFundClassCostsContext(ctx, &models.FundClassCost{FundClassID: class.ID, TICDate: ptrDate(date("2024-09-30")), TIC: c.tic}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, p := range c.prices {
+			p.FundClassID = class.ID
+			if err := store.SaveFundClassPriceContext(ctx, p); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	rankings, err := Calculator{}.PeerSnapshotContext(ctx, store, date("2024-10-17"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]string)
+	for _, r := range rankings {
+		if r.SnapshotDate != date("2024-10-17") {
+			t.Errorf("ranking dated %s, want 2024-10-17", r.SnapshotDate)
+		}
+		got[fmt.Sprintf("%s %s %d", r.Category, r.Metric, r.FundClassID)] = fmt.Sprintf("%d/%d", r.Rank, r.Peers)
+	}
+
+	want := map[string]string{
+		"Equity return_1m 1":  "1/3",
+		"Equity return_1m 4":  "2/3",
+		"Equity return_1m 2":  "3/3",
+		"Equity return_3m 1":  "1/2",
+		"Equity return_3m 2":  "2/2",
+		"Equity return_ytd 1": "1/2",
+		"Equity return_ytd 2": "2/2",
+		"Equity tic 2":        "1/3",
+		"Equity tic 3":        "2/3",
+		"Equity tic 1":        "3/3",
+		"Income return_1m 5":  "1/1",
+		"Income return_3m 5":  "1/1",
+		"Income return_ytd 5": "1/1",
+		"Income tic 5":        "1/1",
+	}
+
+	for key, w := range want {
+		if got[key] != w {
+			t.Errorf("%s = %q, want %s", key, got[key], w)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d rankings %v, want %d", len(got), got, len(want))
+	}
+}
+
+func TestPeerSnapshotRanksTICAsOfItsDate(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+
+	if err := store.SaveCISManagersContext(ctx, []*models.CISManager{{ID: 1, Name: "Manager"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveFundsContext(ctx, []*models.Fund{{TrustNo: 10, Name: "Fund", ManagerID: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	decimal := func(s string) *models.Decimal {
+		d, _ := models.ParseDecimal(s)
+		return &d
+	}
+
+	// A was the cheaper class until the September costs reversed the order,
+	// and C only published costs in September.
+	costs := map[string]map[string]string{
+		"A": {"2024-06-30": "0.80", "2024-09-30": "1.40"},
+		"B": {"2024-06-30": "1.00", "2024-09-30": "0.90"},
+		"C": {"2024-09-30": "0.50"},
+	}
+	ids := make(map[int]string)
+	for _, name := range []string{"A", "B", "C"} {
+		class := &models.FundClass{FundID: 10, ClassName: name, Category: "Equity"}
+		if err := store.SaveFundClassContext(ctx, class); err != nil {
+			t.Fatal(err)
+		}
+		ids[class.ID] = name
+		for ticDate, tic := range costs[name] {
+			if err := store.SaveFundClassCostsContext(ctx, &models.FundClassCost{FundClassID: class.ID, TICDate: ptrDate(date(ticDate)), TIC: decimal(tic)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	tests := map[string]string{
+		"2024-07-31": "A B",
+		"2024-10-17": "C B A",
+	}
+	for asOf, want := range tests {
+		rankings, err := Calculator{}.PeerSnapshotContext(ctx, store, date(asOf))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var order []string
+		for _, r := range rankings {
+			if r.Metric == MetricTIC {
+				order = append(order, ids[r.FundClassID])
+			}
+		}
+		if got := strings.Join(order, " "); got != want {
+			t.Errorf("TIC ranking as of %s = %q, want %q", asOf, got, want)
+		}
+	}
+}
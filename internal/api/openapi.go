@@ -0,0 +1,326 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/analytics"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+// route is one API endpoint. The same table registers the handlers and
+// generates the OpenAPI document, so the two cannot list different paths.
+type route struct {
+	method      string
+	path        string
+	operationID string
+	summary     string
+	params      []parameter
+	// response is the type the handler writes with a 200.
+	response reflect.Type
+	handle   func(s *Server, w http.ResponseWriter, r *http.Request)
+}
+
+type parameter struct {
+	name        string
+	in          string
+	description string
+	schema      map[string]any
+	// notFound describes the 404 of a path parameter.
+	notFound string
+}
+
+var (
+	limitParam = parameter{
+		name: "limit", in: "query", description: "Number of items to return",
+		schema: map[string]any{"type": "integer", "minimum": 1, "maximum": MaxPageLimit, "default": DefaultPageLimit},
+	}
+	offsetParam = parameter{
+		name: "offset", in: "query", description: "Number of items to skip",
+		schema: map[string]any{"type": "integer", "minimum": 0, "default": 0},
+	}
+	trustNoParam = parameter{
+		name: "trustNo", in: "path", description: "ASISA trust number of the fund",
+		schema: map[string]any{"type": "integer"}, notFound: "The fund does not exist",
+	}
+	classIDParam = parameter{
+		name: "id", in: "path", description: "Id of the fund class",
+		schema: map[string]any{"type": "integer"}, notFound: "The fund class does not exist",
+	}
+)
+
+var routes = []route{
+	{
+		method: http.MethodGet, path: "/managers", operationID: "listManagers",
+		summary:  "List the CIS management companies",
+		params:   []parameter{limitParam, offsetParam},
+		response: reflect.TypeFor[page[manager]](),
+		handle:   (*Server).listManagers,
+	},
+	{
+		method: http.MethodGet, path: "/funds", operationID: "listFunds",
+		summary: "List funds, optionally of one manager",
+		params: []parameter{
+			{name: "manager_id", in: "query", description: "Only list the funds of this manager", schema: map[string]any{"type": "integer"}},
+			limitParam, offsetParam,
+		},
+		response: reflect.TypeFor[page[fund]](),
+		handle:   (*Server).listFunds,
+	},
+	{
+		method: http.MethodGet, path: "/funds/{trustNo}", operationID: "getFund",
+		summary:  "Get a fund",
+		params:   []parameter{trustNoParam},
+		response: reflect.TypeFor[fund](),
+		handle:   (*Server).getFund,
+	},
+	{
+		method: http.MethodGet, path: "/funds/{trustNo}/classes", operationID: "listFundClasses",
+		summary:  "List the classes of a fund with their latest costs and price",
+		params:   []parameter{trustNoParam},
+		response: reflect.TypeFor[list[fundClass]](),
+		handle:   (*Server).listFundClasses,
+	},
+	{
+		method: http.MethodGet, path: "/classes/{id}", operationID: "getFundClass",
+		summary:  "Get a fund class with its latest costs and price",
+		params:   []parameter{classIDParam},
+		response: reflect.TypeFor[fundClass](),
+		handle:   (*Server).getFundClass,
+	},
+	{
+		method: http.MethodGet, path: "/classes/{id}/prices", operationID: "listPrices",
+		summary: "List the price history of a fund class, oldest first",
+		params: []parameter{
+			classIDParam,
+			{name: "from", in: "query", description: "First date to include", schema: map[string]any{"type": "string", "format": "date"}},
+			{name: "to", in: "query", description: "Last date to include", schema: map[string]any{"type": "string", "format": "date"}},
+			limitParam, offsetParam,
+		},
+		response: reflect.TypeFor[page[price]](),
+		handle:   (*Server).listPrices,
+	},
+	{
+		method: http.MethodGet, path: "/classes/{id}/analytics", operationID: "getFundClassAnalytics",
+		summary: "Get the returns, volatility, drawdown and Sharpe and Sortino ratios of a fund class over 1M, 3M, YTD, 1Y, 3Y and 5Y",
+		params: []parameter{
+			{
+				name: "id", in: "path", description: classIDParam.description,
+				schema: classIDParam.schema, notFound: "The fund class does not exist or has no prices",
+			},
+			{name: "as_of", in: "query", description: "Last price date to report on, defaults to the latest price", schema: map[string]any{"type": "string", "format": "date"}},
+			{
+				name: "risk_free", in: "query", description: "Annual risk-free rate Sharpe and Sortino are measured against, 0.08 for 8%",
+				schema: map[string]any{"type": "number", "exclusiveMinimum": true, "minimum": -1, "exclusiveMaximum": true, "maximum": 1, "default": 0},
+			},
+		},
+		response: reflect.TypeFor[analyticsReport](),
+		handle:   (*Server).analyse,
+	},
+	{
+		method: http.MethodGet, path: "/classes/{id}/peers", operationID: "listPeerRankings",
+		summary:  "List where a fund class ranks in its category in the latest daily snapshot, by return over each horizon and by TIC",
+		params:   []parameter{classIDParam},
+		response: reflect.TypeFor[list[peerRanking]](),
+		handle:   (*Server).listPeerRankings,
+	},
+	{
+		method: http.MethodGet, path: "/classes/{id}/peers/history", operationID: "listPeerRankingHistory",
+		summary: "List the daily rankings of a fund class in its category on one metric, oldest first",
+		params: []parameter{
+			classIDParam,
+			{
+				name: "metric", in: "query", description: "Metric to list the rankings of",
+				schema: map[string]any{"type": "string", "enum": peerMetrics(), "default": defaultPeerMetric},
+			},
+			{name: "from", in: "query", description: "First snapshot date to include", schema: map[string]any{"type": "string", "format": "date"}},
+			{name: "to", in: "query", description: "Last snapshot date to include", schema: map[string]any{"type": "string", "format": "date"}},
+			limitParam, offsetParam,
+		},
+		response: reflect.TypeFor[page[peerRanking]](),
+		handle:   (*Server).listPeerRankingHistory,
+	},
+	{
+		method: http.MethodGet, path: "/screener", operationID: "screenFundClasses",
+		summary: "Screen fund classes with a filter over their category, fees and latest costs",
+		params: []parameter{
+			{
+				name: "filter", in: "query", schema: map[string]any{"type": "string"},
+				description: `Filter expression, for example class = "Class A" and category ~ equity and tic < 1.5 and add_fee = false. ` +
+					"Fields: " + strings.Join(database.ScreenFields(), ", ") + ". " +
+					"Text takes = != ~ !~, numbers take = != < <= > >=, and comparisons combine with and, or, not and parentheses",
+			},
+			{
+				name: "sort", in: "query", schema: map[string]any{"type": "string"},
+				description: "Field to sort by, prefixed with - to sort descending. Classes without a value come last",
+			},
+			limitParam, offsetParam,
+		},
+		response: reflect.TypeFor[page[screenedClass]](),
+		handle:   (*Server).screen,
+	},
+}
+
+func peerMetrics() []any {
+	var metrics []any
+	for _, metric := range (analytics.Calculator{}).PeerMetrics() {
+		metrics = append(metrics, metric)
+	}
+	return metrics
+}
+
+// Spec returns the OpenAPI 3 document of the API. Its schemas are generated
+// from the response types, which mirror the models with snake_case names.
+func Spec() map[string]any {
+	schemas := make(map[string]any)
+	paths := make(map[string]any)
+
+	for _, rt := range routes {
+		item, ok := paths[rt.path].(map[string]any)
+		if !ok {
+			item = make(map[string]any)
+			paths[rt.path] = item
+		}
+		item[strings.ToLower(rt.method)] = operation(rt, schemas)
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "FundFinderZA API",
+			"description": "Read-only access to the South African unit trusts scraped from the ASISA site.",
+			"version":     "1.0.0",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas},
+	}
+}
+
+func operation(rt route, schemas map[string]any) map[string]any {
+	errorBody := jsonContent(schemaOf(reflect.TypeFor[errorResponse](), schemas))
+
+	responses := map[string]any{
+		"200": map[string]any{"description": "OK", "content": jsonContent(schemaOf(rt.response, schemas))},
+		"500": map[string]any{"description": "The database could not be read", "content": errorBody},
+	}
+
+	params := make([]any, 0, len(rt.params))
+	for _, p := range rt.params {
+		params = append(params, map[string]any{
+			"name":        p.name,
+			"in":          p.in,
+			"description": p.description,
+			"required":    p.in == "path",
+			"schema":      p.schema,
+		})
+
+		responses["400"] = map[string]any{"description": "A parameter is invalid", "content": errorBody}
+		if p.in == "path" {
+			responses["404"] = map[string]any{"description": p.notFound, "content": errorBody}
+		}
+	}
+
+	op := map[string]any{
+		"operationId": rt.operationID,
+		"summary":     rt.summary,
+		"responses":   responses,
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	return op
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+var (
+	dateType    = reflect.TypeFor[models.Date]()
+	decimalType = reflect.TypeFor[models.Decimal]()
+)
+
+// schemaOf returns the JSON schema of t as encoding/json writes it. Named
+// structs are added to schemas once and referenced, the generic list and page
+// wrappers are written inline.
+func schemaOf(t reflect.Type, schemas map[string]any) map[string]any {
+	switch t {
+	case dateType:
+		return map[string]any{"type": "string", "format": "date"}
+	case decimalType:
+		return map[string]any{"type": "number"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := schemaOf(t.Elem(), schemas)
+		if _, isRef := schema["$ref"]; isRef {
+			return map[string]any{"allOf": []any{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		if strings.Contains(t.Name(), "[") {
+			return objectSchema(t, schemas)
+		}
+
+		name := schemaName(t)
+		if _, done := schemas[name]; !done {
+			// Reserve the name first so recursive types terminate.
+			schemas[name] = nil
+			schemas[name] = objectSchema(t, schemas)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	default:
+		panic("api: no OpenAPI schema for " + t.String())
+	}
+}
+
+func objectSchema(t reflect.Type, schemas map[string]any) map[string]any {
+	properties := make(map[string]any)
+	required := []any{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = schemaOf(field.Type, schemas)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// schemaName exports the response type name, so fundClass becomes FundClass.
+func schemaName(t reflect.Type) string {
+	name := []rune(t.Name())
+	name[0] = unicode.ToUpper(name[0])
+	return string(name)
+}
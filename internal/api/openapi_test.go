@@ -0,0 +1,252 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+var update = flag.Bool("update", false, "rewrite openapi.json from the routes")
+
+const specFile = "openapi.json"
+
+// examplePaths fills the path parameters with ids that exist in testStore.
+var examplePaths = strings.NewReplacer("{trustNo}", "1234", "{id}", "1")
+
+// loadSpec round trips Spec through JSON, which is how clients see it.
+func loadSpec(t *testing.T) (map[string]any, []byte) {
+	t.Helper()
+
+	body, err := json.MarshalIndent(Spec(), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body = append(body, '\n')
+
+	var spec map[string]any
+	if err := json.Unmarshal(body, &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	return spec, body
+}
+
+func TestOpenAPISpecIsUpToDate(t *testing.T) {
+	_, body := loadSpec(t)
+
+	if *update {
+		if err := os.WriteFile(specFile, body, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	committed, err := os.ReadFile(specFile)
+	if err != nil {
+		t.Fatalf("error reading %s, run go test ./internal/api -update to write it: %v", specFile, err)
+	}
+
+	if !bytes.Equal(committed, body) {
+		t.Errorf("%s is out of date with the routes, run go test ./internal/api -update", specFile)
+	}
+
+	recorder := httptest.NewRecorder()
+	NewServer(testStore(t)).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if recorder.Code != http.StatusOK || !bytes.Equal(recorder.Body.Bytes(), compact(t, body)) {
+		t.Errorf("GET /openapi.json = %d, want the generated spec", recorder.Code)
+	}
+}
+
+// TestHandlersMatchSpec calls every documented operation and checks the
+// responses against the document: bodies must match their schema, and every
+// documented query parameter must be read by the handler.
+func TestHandlersMatchSpec(t *testing.T) {
+	spec, _ := loadSpec(t)
+	server := NewServer(testStore(t))
+
+	paths := spec["paths"].(map[string]any)
+	for _, path := range sortedKeys(paths) {
+		for _, method := range sortedKeys(paths[path].(map[string]any)) {
+			op := paths[path].(map[string]any)[method].(map[string]any)
+
+			t.Run(strings.ToUpper(method)+" "+path, func(t *testing.T) {
+				call(t, spec, server, op, method, examplePaths.Replace(path), http.StatusOK)
+
+				if strings.Contains(path, "{") {
+					missing := strings.NewReplacer("{trustNo}", "9999", "{id}", "9999").Replace(path)
+					call(t, spec, server, op, method, missing, http.StatusNotFound)
+
+					invalid := strings.NewReplacer("{trustNo}", "x", "{id}", "x").Replace(path)
+					call(t, spec, server, op, method, invalid, http.StatusBadRequest)
+				}
+
+				params, _ := op["parameters"].([]any)
+				for _, p := range params {
+					param := p.(map[string]any)
+					if param["in"] != "query" {
+						continue
+					}
+
+					target := examplePaths.Replace(path) + "?" + param["name"].(string) + "=not-valid"
+					call(t, spec, server, op, method, target, http.StatusBadRequest)
+				}
+			})
+		}
+	}
+}
+
+func TestRoutesAreServed(t *testing.T) {
+	server := NewServer(testStore(t))
+
+	for _, rt := range routes {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(rt.method, examplePaths.Replace(rt.path), nil))
+		if recorder.Code != http.StatusOK {
+			t.Errorf("%s %s = %d, want 200", rt.method, rt.path, recorder.Code)
+		}
+	}
+}
+
+// call requests target and validates the response against the operation.
+func call(t *testing.T, spec map[string]any, server http.Handler, op map[string]any, method string, target string, wantStatus int) {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(strings.ToUpper(method), target, nil))
+
+	if recorder.Code != wantStatus {
+		t.Fatalf("%s = %d %s, want %d", target, recorder.Code, recorder.Body, wantStatus)
+	}
+
+	response, ok := op["responses"].(map[string]any)[fmt.Sprint(recorder.Code)].(map[string]any)
+	if !ok {
+		t.Fatalf("%s answered %d, which the spec does not document", target, recorder.Code)
+	}
+
+	schema := response["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)
+
+	var body any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%s: decoding %s: %v", target, recorder.Body, err)
+	}
+
+	for _, problem := range validate(spec, schema, body, "body") {
+		t.Errorf("%s: %s", target, problem)
+	}
+}
+
+// validate checks value against the subset of JSON schema that Spec writes.
+func validate(spec map[string]any, schema map[string]any, value any, at string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		resolved, ok := spec["components"].(map[string]any)["schemas"].(map[string]any)[name].(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: unknown schema %s", at, ref)}
+		}
+		return validate(spec, resolved, value, at)
+	}
+
+	if value == nil {
+		if schema["nullable"] == true {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: null is not nullable", at)}
+	}
+
+	if allOf, ok := schema["allOf"].([]any); ok {
+		var problems []string
+		for _, s := range allOf {
+			problems = append(problems, validate(spec, s.(map[string]any), value, at)...)
+		}
+		return problems
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %v is not an object", at, value)}
+		}
+
+		var problems []string
+		properties := schema["properties"].(map[string]any)
+		for _, name := range schema["required"].([]any) {
+			if _, ok := object[name.(string)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: required %s is missing", at, name))
+			}
+		}
+		for _, name := range sortedKeys(object) {
+			property, ok := properties[name].(map[string]any)
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: %s is not in the schema", at, name))
+				continue
+			}
+			problems = append(problems, validate(spec, property, object[name], at+"."+name)...)
+		}
+		return problems
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %v is not an array", at, value)}
+		}
+
+		var problems []string
+		for i, item := range array {
+			problems = append(problems, validate(spec, schema["items"].(map[string]any), item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+		return problems
+	case "integer":
+		if n, ok := value.(float64); !ok || n != float64(int64(n)) {
+			return []string{fmt.Sprintf("%s: %v is not an integer", at, value)}
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return []string{fmt.Sprintf("%s: %v is not a number", at, value)}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{fmt.Sprintf("%s: %v is not a boolean", at, value)}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %v is not a string", at, value)}
+		}
+		if schema["format"] == "date" {
+			if _, err := models.ParseDate(s); err != nil {
+				return []string{fmt.Sprintf("%s: %v", at, err)}
+			}
+		}
+	default:
+		return []string{fmt.Sprintf("%s: schema has no type", at)}
+	}
+
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func compact(t *testing.T, body []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, body); err != nil {
+		t.Fatal(err)
+	}
+	return append(buf.Bytes(), '\n')
+}
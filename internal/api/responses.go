@@ -0,0 +1,243 @@
+package api
+
+import (
+	"github.com/Alexandervanderleek/FundFinderZA/internal/analytics"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+// The response types give the API its own snake_case JSON, so the models can
+// change without changing what clients see.
+
+type manager struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func newManager(m *models.CISManager) manager {
+	return manager{ID: m.ID, Name: m.Name}
+}
+
+type fund struct {
+	TrustNo       int    `json:"trust_no"`
+	Name          string `json:"name"`
+	SecondaryName string `json:"secondary_name,omitempty"`
+	ManagerID     int    `json:"manager_id"`
+}
+
+func newFund(f *models.Fund) fund {
+	return fund{TrustNo: f.TrustNo, Name: f.Name, SecondaryName: f.SecondaryName, ManagerID: f.ManagerID}
+}
+
+type fundClass struct {
+	ID           int             `json:"id"`
+	FundID       int             `json:"fund_id"`
+	ClassName    string          `json:"class_name"`
+	TargetMarket string          `json:"target_market,omitempty"`
+	Category     string          `json:"category,omitempty"`
+	AddFee       bool            `json:"add_fee"`
+	MaxInitFee   *models.Decimal `json:"max_init_fee"`
+	LatestCosts  *costs          `json:"latest_costs"`
+	LatestPrice  *price          `json:"latest_price"`
+}
+
+func newFundClass(fc *models.FundClass, latestCosts *models.FundClassCost, latestPrice *models.FundClassPrice) fundClass {
+	class := fundClass{
+		ID:           fc.ID,
+		FundID:       fc.FundID,
+		ClassName:    fc.ClassName,
+		TargetMarket: fc.TargetMarket,
+		Category:     fc.Category,
+		AddFee:       fc.AddFee,
+		MaxInitFee:   fc.MaxInitFee,
+	}
+
+	if latestCosts != nil {
+		class.LatestCosts = &costs{
+			Date:        latestCosts.TICDate,
+			TERPerfComp: latestCosts.TERPerfComp,
+			TER:         latestCosts.TER,
+			TC:          latestCosts.TC,
+			TIC:         latestCosts.TIC,
+		}
+	}
+
+	if latestPrice != nil {
+		p := newPrice(latestPrice)
+		class.LatestPrice = &p
+	}
+
+	return class
+}
+
+// costs are percentages, as printed on the LatestPrices page.
+type costs struct {
+	Date        *models.Date    `json:"date"`
+	TERPerfComp *models.Decimal `json:"ter_perf_comp"`
+	TER         *models.Decimal `json:"ter"`
+	TC          *models.Decimal `json:"tc"`
+	TIC         *models.Decimal `json:"tic"`
+}
+
+type price struct {
+	Date *models.Date    `json:"date"`
+	NAV  *models.Decimal `json:"nav"`
+}
+
+func newPrice(p *models.FundClassPrice) price {
+	return price{Date: p.PriceDate, NAV: p.NAV}
+}
+
+// screenedClass is a fund class as the screener returns it, with its fund,
+// manager and latest costs.
+type screenedClass struct {
+	ID           int             `json:"id"`
+	TrustNo      int             `json:"trust_no"`
+	FundName     string          `json:"fund_name"`
+	ClassName    string          `json:"class_name"`
+	ManagerID    int             `json:"manager_id"`
+	ManagerName  string          `json:"manager_name"`
+	Category     string          `json:"category,omitempty"`
+	TargetMarket string          `json:"target_market,omitempty"`
+	AddFee       bool            `json:"add_fee"`
+	MaxInitFee   *models.Decimal `json:"max_init_fee"`
+	LatestCosts  *costs          `json:"latest_costs"`
+}
+
+func newScreenedClass(s *models.FundClassSummary) screenedClass {
+	class := screenedClass{
+		ID:           s.FundClassID,
+		TrustNo:      s.TrustNo,
+		FundName:     s.FundName,
+		ClassName:    s.ClassName,
+		ManagerID:    s.ManagerID,
+		ManagerName:  s.ManagerName,
+		Category:     s.Category,
+		TargetMarket: s.TargetMarket,
+		AddFee:       s.AddFee,
+		MaxInitFee:   s.MaxInitFee,
+	}
+
+	if s.CostsDate != nil {
+		class.LatestCosts = &costs{Date: s.CostsDate, TERPerfComp: s.TERPerfComp, TER: s.TER, TC: s.TC, TIC: s.TIC}
+	}
+
+	return class
+}
+
+// analyticsReport holds returns, volatility and drawdown as fractions, 0.05
+// for 5%.
+type analyticsReport struct {
+	FundClassID  int         `json:"fund_class_id"`
+	AsOf         models.Date `json:"as_of"`
+	NAV          float64     `json:"nav"`
+	RiskFreeRate float64     `json:"risk_free_rate"`
+	Periods      []period    `json:"periods"`
+}
+
+// period has null stats when the price history does not reach back to start.
+type period struct {
+	Horizon string       `json:"horizon"`
+	Start   models.Date  `json:"start"`
+	End     models.Date  `json:"end"`
+	Stats   *periodStats `json:"stats"`
+}
+
+type periodStats struct {
+	BaseDate          models.Date  `json:"base_date"`
+	BaseNAV           float64      `json:"base_nav"`
+	Observations      int          `json:"observations"`
+	CumulativeReturn  float64      `json:"cumulative_return"`
+	AnnualisedReturn  *float64     `json:"annualised_return"`
+	Volatility        *float64     `json:"volatility"`
+	MaxDrawdown       float64      `json:"max_drawdown"`
+	MaxDrawdownPeak   *models.Date `json:"max_drawdown_peak"`
+	MaxDrawdownTrough *models.Date `json:"max_drawdown_trough"`
+	Sharpe            *float64     `json:"sharpe"`
+	Sortino           *float64     `json:"sortino"`
+	LongestGapDays    int          `json:"longest_gap_days"`
+}
+
+func newAnalyticsReport(r *analytics.Report) analyticsReport {
+	report := analyticsReport{
+		FundClassID:  r.FundClassID,
+		AsOf:         r.AsOf,
+		NAV:          r.NAV,
+		RiskFreeRate: r.RiskFreeRate,
+		Periods:      make([]period, 0, len(r.Periods)),
+	}
+
+	for _, p := range r.Periods {
+		out := period{Horizon: p.Horizon, Start: p.Start, End: p.End}
+		if s := p.Stats; s != nil {
+			out.Stats = &periodStats{
+				BaseDate:          s.BaseDate,
+				BaseNAV:           s.BaseNAV,
+				Observations:      s.Observations,
+				CumulativeReturn:  s.CumulativeReturn,
+				AnnualisedReturn:  s.AnnualisedReturn,
+				Volatility:        s.Volatility,
+				MaxDrawdown:       s.MaxDrawdown,
+				MaxDrawdownPeak:   s.MaxDrawdownPeak,
+				MaxDrawdownTrough: s.MaxDrawdownTrough,
+				Sharpe:            s.Sharpe,
+				Sortino:           s.Sortino,
+				LongestGapDays:    s.LongestGapDays,
+			}
+		}
+		report.Periods = append(report.Periods, out)
+	}
+
+	return report
+}
+
+// peerRanking places a fund class among its category on one metric. Rank,
+// quartile and percentile 1 are the best. Value is a fraction for returns and
+// a percentage for the TIC.
+type peerRanking struct {
+	SnapshotDate models.Date `json:"snapshot_date"`
+	Category     string      `json:"category"`
+	Metric       string      `json:"metric"`
+	Value        float64     `json:"value"`
+	Rank         int         `json:"rank"`
+	Peers        int         `json:"peers"`
+	Quartile     int         `json:"quartile"`
+	Percentile   int         `json:"percentile"`
+}
+
+func newPeerRanking(r *models.PeerRanking) peerRanking {
+	return peerRanking{
+		SnapshotDate: r.SnapshotDate,
+		Category:     r.Category,
+		Metric:       r.Metric,
+		Value:        r.Value,
+		Rank:         r.Rank,
+		Peers:        r.Peers,
+		Quartile:     r.Quartile,
+		Percentile:   r.Percentile,
+	}
+}
+
+type list[T any] struct {
+	Data []T `json:"data"`
+}
+
+// page is a list cut down to the requested limit and offset.
+type page[T any] struct {
+	Data       []T        `json:"data"`
+	Pagination pagination `json:"pagination"`
+}
+
+type pagination struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	Total  int `json:"total"`
+}
+
+func newPagination(p database.Page, total int) pagination {
+	return pagination{Limit: p.Limit, Offset: p.Offset, Total: total}
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,509 @@
+// Package api serves the fund database over a read-only JSON HTTP API.
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/analytics"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+const (
+	// DefaultPageLimit is the page size used when a request has no limit.
+	DefaultPageLimit = 50
+	// MaxPageLimit caps the limit a request may ask for.
+	MaxPageLimit = 500
+)
+
+// defaultPeerMetric is the ranking history served when a request names none.
+var defaultPeerMetric = analytics.ReturnMetric("1Y")
+
+// Server routes the API requests to a database.ReadStore.
+type Server struct {
+	store database.ReadStore
+	mux   *http.ServeMux
+}
+
+// NewServer registers every route in routes, which also describe the API in
+// the OpenAPI document served at /openapi.json.
+func NewServer(store database.ReadStore) *Server {
+	s := &Server{store: store, mux: http.NewServeMux()}
+
+	for _, rt := range routes {
+		s.mux.HandleFunc(rt.method+" "+rt.path, func(w http.ResponseWriter, r *http.Request) {
+			rt.handle(s, w, r)
+		})
+	}
+
+	s.mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, Spec())
+	})
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) listManagers(w http.ResponseWriter, r *http.Request) {
+	p, err := parsePage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	managers, total, err := s.store.ListCISManagersContext(r.Context(), p)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data := make([]manager, 0, len(managers))
+	for _, m := range managers {
+		data = append(data, newManager(m))
+	}
+
+	writeJSON(w, http.StatusOK, page[manager]{Data: data, Pagination: newPagination(p, total)})
+}
+
+func (s *Server) listFunds(w http.ResponseWriter, r *http.Request) {
+	p, err := parsePage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	managerID, err := queryInt(r, "manager_id", 0)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	funds, total, err := s.store.ListFundsContext(r.Context(), managerID, p)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data := make([]fund, 0, len(funds))
+	for _, f := range funds {
+		data = append(data, newFund(f))
+	}
+
+	writeJSON(w, http.StatusOK, page[fund]{Data: data, Pagination: newPagination(p, total)})
+}
+
+func (s *Server) getFund(w http.ResponseWriter, r *http.Request) {
+	f, err := s.findFund(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newFund(f))
+}
+
+func (s *Server) listFundClasses(w http.ResponseWriter, r *http.Request) {
+	f, err := s.findFund(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	classes, err := s.fundClasses(r, f.TrustNo)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list[fundClass]{Data: classes})
+}
+
+func (s *Server) getFundClass(w http.ResponseWriter, r *http.Request) {
+	fc, err := s.findFundClass(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	costs, prices, err := s.latest(r, fc.FundID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newFundClass(fc, costs[fc.ID], prices[fc.ID]))
+}
+
+func (s *Server) listPrices(w http.ResponseWriter, r *http.Request) {
+	fc, err := s.findFundClass(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	p, err := parsePage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	from, err := queryDate(r, "from")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	to, err := queryDate(r, "to")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if from != nil && to != nil && to.Before(*from) {
+		writeError(w, badRequest("to %s is before from %s", to, from))
+		return
+	}
+
+	prices, total, err := s.store.ListFundClassPricesContext(r.Context(), fc.ID, from, to, p)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data := make([]price, 0, len(prices))
+	for _, p := range prices {
+		data = append(data, newPrice(p))
+	}
+
+	writeJSON(w, http.StatusOK, page[price]{Data: data, Pagination: newPagination(p, total)})
+}
+
+func (s *Server) screen(w http.ResponseWriter, r *http.Request) {
+	p, err := parsePage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	screen := database.Screen{Filter: query.Get("filter"), Sort: query.Get("sort")}
+
+	summaries, total, err := s.store.ScreenFundClassesContext(r.Context(), screen, p)
+	var filterErr *database.FilterError
+	if errors.As(err, &filterErr) {
+		writeError(w, badRequest("%s", filterErr))
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data := make([]screenedClass, 0, len(summaries))
+	for _, summary := range summaries {
+		data = append(data, newScreenedClass(summary))
+	}
+
+	writeJSON(w, http.StatusOK, page[screenedClass]{Data: data, Pagination: newPagination(p, total)})
+}
+
+func (s *Server) analyse(w http.ResponseWriter, r *http.Request) {
+	fc, err := s.findFundClass(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	asOf, err := queryDate(r, "as_of")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	riskFree, err := queryFloat(r, "risk_free", 0)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if riskFree <= -1 || riskFree >= 1 {
+		writeError(w, badRequest("risk_free must be an annual rate between -1 and 1, 0.08 for 8%%"))
+		return
+	}
+
+	calculator := analytics.Calculator{RiskFreeRate: riskFree}
+	report, err := calculator.FundClassContext(r.Context(), s.store, fc.ID, asOf)
+	if errors.Is(err, analytics.ErrNoPrices) {
+		writeError(w, notFound("fund class %d has no prices", fc.ID))
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newAnalyticsReport(report))
+}
+
+func (s *Server) listPeerRankings(w http.ResponseWriter, r *http.Request) {
+	fc, err := s.findFundClass(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	rankings, err := s.store.GetLatestPeerRankingsContext(r.Context(), fc.ID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data := make([]peerRanking, 0, len(rankings))
+	for _, ranking := range rankings {
+		data = append(data, newPeerRanking(ranking))
+	}
+
+	writeJSON(w, http.StatusOK, list[peerRanking]{Data: data})
+}
+
+func (s *Server) listPeerRankingHistory(w http.ResponseWriter, r *http.Request) {
+	fc, err := s.findFundClass(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	p, err := parsePage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	metric := r.URL.Query().Get("metric")
+	if metric == "" {
+		metric = defaultPeerMetric
+	}
+	if metrics := (analytics.Calculator{}).PeerMetrics(); !slices.Contains(metrics, metric) {
+		writeError(w, badRequest("metric must be one of %s, got %q", strings.Join(metrics, ", "), metric))
+		return
+	}
+
+	from, err := queryDate(r, "from")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	to, err := queryDate(r, "to")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	rankings, total, err := s.store.ListPeerRankingsContext(r.Context(), fc.ID, metric, from, to, p)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data := make([]peerRanking, 0, len(rankings))
+	for _, ranking := range rankings {
+		data = append(data, newPeerRanking(ranking))
+	}
+
+	writeJSON(w, http.StatusOK, page[peerRanking]{Data: data, Pagination: newPagination(p, total)})
+}
+
+// fundClasses returns the classes of a fund with their latest costs and price.
+func (s *Server) fundClasses(r *http.Request, trustNo int) ([]fundClass, error) {
+	classes, err := s.store.GetFundClassesByFundContext(r.Context(), trustNo)
+	if err != nil {
+		return nil, err
+	}
+
+	costs, prices, err := s.latest(r, trustNo)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]fundClass, 0, len(classes))
+	for _, fc := range classes {
+		data = append(data, newFundClass(fc, costs[fc.ID], prices[fc.ID]))
+	}
+
+	return data, nil
+}
+
+// latest returns the latest costs and price of each class of a fund, keyed by
+// fund class id.
+func (s *Server) latest(r *http.Request, trustNo int) (map[int]*models.FundClassCost, map[int]*models.FundClassPrice, error) {
+	costs, err := s.store.GetLatestFundClassCostsContext(r.Context(), trustNo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prices, err := s.store.GetLatestFundClassPricesContext(r.Context(), trustNo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return costs, prices, nil
+}
+
+func (s *Server) findFund(r *http.Request) (*models.Fund, error) {
+	trustNo, err := pathInt(r, "trustNo")
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := s.store.GetFundContext(r.Context(), trustNo)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, notFound("no fund with trust number %d", trustNo)
+	}
+
+	return f, nil
+}
+
+func (s *Server) findFundClass(r *http.Request) (*models.FundClass, error) {
+	id, err := pathInt(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	fc, err := s.store.GetFundClassContext(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	if fc == nil {
+		return nil, notFound("no fund class with id %d", id)
+	}
+
+	return fc, nil
+}
+
+// parsePage reads the limit and offset query parameters.
+func parsePage(r *http.Request) (database.Page, error) {
+	limit, err := queryInt(r, "limit", DefaultPageLimit)
+	if err != nil {
+		return database.Page{}, err
+	}
+	if limit < 1 || limit > MaxPageLimit {
+		return database.Page{}, badRequest("limit must be between 1 and %d", MaxPageLimit)
+	}
+
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		return database.Page{}, err
+	}
+	if offset < 0 {
+		return database.Page{}, badRequest("offset must not be negative")
+	}
+
+	return database.Page{Limit: limit, Offset: offset}, nil
+}
+
+func queryInt(r *http.Request, name string, fallback int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, badRequest("%s must be a whole number, got %q", name, value)
+	}
+
+	return n, nil
+}
+
+func queryFloat(r *http.Request, name string, fallback float64) (float64, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, badRequest("%s must be a number, got %q", name, value)
+	}
+
+	return f, nil
+}
+
+// queryDate returns nil when the parameter is not set.
+func queryDate(r *http.Request, name string) (*models.Date, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := models.ParseDate(value)
+	if err != nil {
+		return nil, badRequest("%s must be a YYYY-MM-DD date, got %q", name, value)
+	}
+
+	return &date, nil
+}
+
+func pathInt(r *http.Request, name string) (int, error) {
+	value := r.PathValue(name)
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, badRequest("%s must be a whole number, got %q", name, value)
+	}
+
+	return n, nil
+}
+
+// httpError is an error with the status code it should be answered with.
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+func badRequest(format string, args ...any) error {
+	return &httpError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+func notFound(format string, args ...any) error {
+	return &httpError{status: http.StatusNotFound, message: fmt.Sprintf(format, args...)}
+}
+
+// writeError answers with the status of an httpError. Other errors come from
+// the store, they are logged and answered with a 500 that does not leak them.
+func writeError(w http.ResponseWriter, err error) {
+	var httpErr *httpError
+	if !errors.As(err, &httpErr) {
+		log.Printf("Error serving request: %v\n", err)
+		httpErr = &httpError{status: http.StatusInternalServerError, message: "internal server error"}
+	}
+
+	writeJSON(w, httpErr.status, errorResponse{Error: httpErr.message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Error writing response: %v\n", err)
+	}
+}
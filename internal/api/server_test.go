@@ -0,0 +1,297 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/database"
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+func testStore(t *testing.T) *database.MemoryStore {
+	t.Helper()
+
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+
+	managers := []*models.CISManager{{ID: 37, Name: "Coronation"}, {ID: 303, Name: "Allan Gray"}, {ID: 412, Name: "Prescient"}}
+	if err := store.SaveCISManagersContext(ctx, managers); err != nil {
+		t.Fatal(err)
+	}
+
+	funds := []*models.Fund{
+		{TrustNo: 1234, Name: "Allan Gray Balanced Fund", ManagerID: 303},
+		{TrustNo: 1235, Name: "Allan Gray Equity Fund", ManagerID: 303},
+		{TrustNo: 2001, Name: "Coronation Top 20 Fund", ManagerID: 37},
+	}
+	if err := store.SaveFundsContext(ctx, funds); err != nil {
+		t.Fatal(err)
+	}
+
+	class := &models.FundClass{FundID: 1234, ClassName: "Class A", TargetMarket: "Retail", Category: "SA--Multi Asset--High Equity"}
+	if err := store.SaveFundClassContext(ctx, class); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []struct{ date, nav string }{
+		{"2024-10-01", "120.10"},
+		{"2024-10-02", "121.50"},
+		{"2024-10-03", "119.95"},
+		{"2024-10-04", "123.97"},
+	} {
+		date, _ := models.ParseDate(p.date)
+		nav, _ := models.ParseDecimal(p.nav)
+		if err := store.SaveFundClassPriceContext(ctx, &models.FundClassPrice{FundClassID: class.ID, PriceDate: &date, NAV: &nav}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, c := range []struct{ date, ter string }{{"2024-06-30", "1.41"}, {"2024-09-30", "1.38"}} {
+		date, _ := models.ParseDate(c.date)
+		ter, _ := models.ParseDecimal(c.ter)
+		if err := store.SaveFundClassCostsContext(ctx, &models.FundClassCost{FundClassID: class.ID, TICDate: &date, TER: &ter}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return store
+}
+
+// get requests path and decodes the JSON body into out.
+func get(t *testing.T, server http.Handler, path string, wantStatus int, out any) {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+	if recorder.Code != wantStatus {
+		t.Fatalf("GET %s = %d %s, want %d", path, recorder.Code, recorder.Body, wantStatus)
+	}
+	if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("GET %s Content-Type = %q, want application/json", path, got)
+	}
+
+	if err := json.Unmarshal(recorder.Body.Bytes(), out); err != nil {
+		t.Fatalf("GET %s: decoding %s: %v", path, recorder.Body, err)
+	}
+}
+
+func TestListManagersPaginates(t *testing.T) {
+	server := NewServer(testStore(t))
+
+	var got page[manager]
+	get(t, server, "/managers?limit=2&offset=1", http.StatusOK, &got)
+
+	if got.Pagination != (pagination{Limit: 2, Offset: 1, Total: 3}) {
+		t.Errorf("pagination = %+v, want limit 2, offset 1, total 3", got.Pagination)
+	}
+	if len(got.Data) != 2 || got.Data[0].ID != 303 || got.Data[1].ID != 412 {
+		t.Errorf("data = %+v, want managers 303 and 412", got.Data)
+	}
+}
+
+func TestListFundsByManager(t *testing.T) {
+	server := NewServer(testStore(t))
+
+	var got page[fund]
+	get(t, server, "/funds?manager_id=303", http.StatusOK, &got)
+
+	if len(got.Data) != 2 || got.Pagination.Total != 2 || got.Pagination.Limit != DefaultPageLimit {
+		t.Errorf("got %+v %+v, want the 2 Allan Gray funds with the default limit", got.Data, got.Pagination)
+	}
+}
+
+func TestGetFund(t *testing.T) {
+	server := NewServer(testStore(t))
+
+	var got fund
+	get(t, server, "/funds/2001", http.StatusOK, &got)
+	if want := (fund{TrustNo: 2001, Name: "Coronation Top 20 Fund", ManagerID: 37}); got != want {
+		t.Errorf("fund = %+v, want %+v", got, want)
+	}
+
+	var notFound errorResponse
+	get(t, server, "/funds/9999", http.StatusNotFound, &notFound)
+	if notFound.Error == "" {
+		t.Error("404 response has no error message")
+	}
+}
+
+func TestListFundClassesIncludesLatestCostsAndPrice(t *testing.T) {
+	server := NewServer(testStore(t))
+
+	var classes list[fundClass]
+	get(t, server, "/funds/1234/classes", http.StatusOK, &classes)
+
+	if len(classes.Data) != 1 {
+		t.Fatalf("got %d classes, want 1", len(classes.Data))
+	}
+
+	class := classes.Data[0]
+	if class.LatestCosts == nil || class.LatestCosts.Date.String() != "2024-09-30" || class.LatestCosts.TER.String() != "1.38" {
+		t.Errorf("latest costs = %+v, want the 2024-09-30 TER of 1.38", class.LatestCosts)
+	}
+	if class.LatestPrice == nil || class.LatestPrice.Date.String() != "2024-10-04" || class.LatestPrice.NAV.String() != "123.97" {
+		t.Errorf("latest price = %+v, want 123.97 on 2024-10-04", class.LatestPrice)
+	}
+
+	var empty list[fundClass]
+	get(t, server, "/funds/2001/classes", http.StatusOK, &empty)
+	if empty.Data == nil || len(empty.Data) != 0 {
+		t.Errorf("data = %v, want an empty list", empty.Data)
+	}
+}
+
+func TestListPricesFiltersByDate(t *testing.T) {
+	server := NewServer(testStore(t))
+
+	var prices page[price]
+	get(t, server, "/classes/1/prices?from=2024-10-02&to=2024-10-03", http.StatusOK, &prices)
+
+	if prices.Pagination.Total != 2 || len(prices.Data) != 2 {
+		t.Fatalf("got %d of %d prices, want 2 of 2", len(prices.Data), prices.Pagination.Total)
+	}
+	if prices.Data[0].Date.String() != "2024-10-02" || prices.Data[1].NAV.String() != "119.95" {
+		t.Errorf("prices = %+v, want 2024-10-02 and 2024-10-03 oldest first", prices.Data)
+	}
+}
+
+func TestScreener(t *testing.T) {
+	server := NewServer(testStore(t))
+
+	var got page[screenedClass]
+	get(t, server, "/screener?filter=category+~+%27multi+asset%27+and+ter+%3C+1.4&sort=-ter", http.StatusOK, &got)
+
+	if got.Pagination.Total != 1 || len(got.Data) != 1 {
+		t.Fatalf("got %d of %d classes, want 1 of 1", len(got.Data), got.Pagination.Total)
+	}
+
+	class := got.Data[0]
+	if class.TrustNo != 1234 || class.ManagerName != "Allan Gray" || class.LatestCosts == nil || class.LatestCosts.TER.String() != "1.38" {
+		t.Errorf("class = %+v, want the Allan Gray Balanced Fund class with its 1.38 TER", class)
+	}
+
+	var none page[screenedClass]
+	get(t, server, "/screener?filter=ter+%3E+1.4", http.StatusOK, &none)
+	if none.Data == nil || none.Pagination.Total != 0 {
+		t.Errorf("got %+v, want an empty page", none)
+	}
+}
+
+func TestFundClassAnalytics(t *testing.T) {
+	store := testStore(t)
+	ctx := context.Background()
+
+	yearEnd, _ := models.ParseDate("2023-12-29")
+	nav, _ := models.ParseDecimal("100.00")
+	if err := store.SaveFundClassPriceContext(ctx, &models.FundClassPrice{FundClassID: 1, PriceDate: &yearEnd, NAV: &nav}); err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(store)
+
+	var got analyticsReport
+	get(t, server, "/classes/1/analytics?risk_free=0.08&as_of=2024-10-03", http.StatusOK, &got)
+
+	if got.AsOf.String() != "2024-10-03" || got.NAV != 119.95 || got.RiskFreeRate != 0.08 || len(got.Periods) != 6 {
+		t.Fatalf("report = %+v, want 6 periods as of 2024-10-03 at 119.95", got)
+	}
+
+	for _, p := range got.Periods {
+		if p.Horizon != "YTD" {
+			if p.Stats != nil {
+				t.Errorf("%s stats = %+v, want none for a history starting in December", p.Horizon, p.Stats)
+			}
+			continue
+		}
+
+		if p.Stats == nil || p.Stats.BaseDate.String() != "2023-12-29" || p.Stats.Observations != 4 {
+			t.Fatalf("YTD stats = %+v, want 4 prices from 2023-12-29", p.Stats)
+		}
+		if diff := p.Stats.CumulativeReturn - 0.1995; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("YTD return = %v, want 0.1995", p.Stats.CumulativeReturn)
+		}
+		if p.Stats.MaxDrawdownPeak == nil || p.Stats.MaxDrawdownPeak.String() != "2024-10-02" {
+			t.Errorf("drawdown peak = %v, want 2024-10-02", p.Stats.MaxDrawdownPeak)
+		}
+	}
+
+	priceless := &models.FundClass{FundID: 1235, ClassName: "Class A", TargetMarket: "Retail"}
+	if err := store.SaveFundClassContext(ctx, priceless); err != nil {
+		t.Fatal(err)
+	}
+
+	var response errorResponse
+	get(t, server, fmt.Sprintf("/classes/%d/analytics", priceless.ID), http.StatusNotFound, &response)
+}
+
+func TestPeerRankings(t *testing.T) {
+	store := testStore(t)
+	ctx := context.Background()
+
+	for _, snapshot := range []struct {
+		date     string
+		quartile int
+	}{{"2024-10-03", 2}, {"2024-10-04", 1}} {
+		date, _ := models.ParseDate(snapshot.date)
+		rankings := []*models.PeerRanking{
+			{FundClassID: 1, Category: "SA--Multi Asset--High Equity", Metric: "return_1y", Value: 0.12, Rank: snapshot.quartile, Peers: 4, Quartile: snapshot.quartile, Percentile: 1 + (snapshot.quartile-1)*33},
+			{FundClassID: 1, Category: "SA--Multi Asset--High Equity", Metric: "tic", Value: 1.38, Rank: 3, Peers: 4, Quartile: 3, Percentile: 67},
+		}
+		if err := store.SavePeerRankingsContext(ctx, date, rankings); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := NewServer(store)
+
+	var latest list[peerRanking]
+	get(t, server, "/classes/1/peers", http.StatusOK, &latest)
+	if len(latest.Data) != 2 || latest.Data[0].Metric != "return_1y" || latest.Data[0].Quartile != 1 || latest.Data[0].SnapshotDate.String() != "2024-10-04" {
+		t.Errorf("latest = %+v, want the 2024-10-04 return_1y and tic rankings", latest.Data)
+	}
+
+	var history page[peerRanking]
+	get(t, server, "/classes/1/peers/history", http.StatusOK, &history)
+	if history.Pagination.Total != 2 || history.Data[0].Quartile != 2 || history.Data[1].Quartile != 1 {
+		t.Errorf("history = %+v, want return_1y moving from quartile 2 to 1", history.Data)
+	}
+
+	var tic page[peerRanking]
+	get(t, server, "/classes/1/peers/history?metric=tic&from=2024-10-04", http.StatusOK, &tic)
+	if tic.Pagination.Total != 1 || tic.Data[0].Value != 1.38 {
+		t.Errorf("tic history = %+v, want the 1.38 TIC ranking", tic.Data)
+	}
+}
+
+func TestBadRequests(t *testing.T) {
+	server := NewServer(testStore(t))
+
+	for _, path := range []string{
+		"/managers?limit=0",
+		"/managers?limit=501",
+		"/managers?offset=-1",
+		"/funds?manager_id=abc",
+		"/funds/abc",
+		"/classes/1/prices?from=01/10/2024",
+		"/classes/1/prices?from=2024-10-03&to=2024-10-01",
+		"/screener?filter=tic+%3C",
+		"/screener?filter=fees+%3D+0",
+		"/screener?sort=fees",
+		"/classes/1/analytics?risk_free=8",
+		"/classes/1/peers/history?metric=return_2y",
+	} {
+		var response errorResponse
+		get(t, server, path, http.StatusBadRequest, &response)
+		if response.Error == "" {
+			t.Errorf("GET %s: 400 response has no error message", path)
+		}
+	}
+
+	var response errorResponse
+	get(t, server, "/classes/99/prices", http.StatusNotFound, &response)
+}
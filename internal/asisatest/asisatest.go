@@ -0,0 +1,333 @@
+// Package asisatest serves a local stand-in for the ASISA fund price pages so
+// the scraper can be exercised end to end without the network.
+//
+// The fake mimics the parts of the site the scraper depends on:
+// HistPriceLookUp.aspx with its ViewState fields, MANCO_ID select, the
+// post-back that fills the TrustNo select and the historical price table, and
+// LatestPrices.aspx with its manager, sector and fund rows.
+package asisatest
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/scraper"
+)
+
+// Scenario is the data the fake site serves.
+type Scenario struct {
+	Managers []Manager
+}
+
+type Manager struct {
+	ID    int
+	Name  string
+	Funds []Fund
+}
+
+type Fund struct {
+	TrustNo int
+	Name    string
+	// ListedName is the name used on LatestPrices.aspx, which often differs
+	// from the HistPriceLookUp name. Defaults to Name.
+	ListedName string
+	Category   string
+	Classes    []Class
+}
+
+// Class is one class of a fund. Cost and fee fields are rendered verbatim and
+// shown as "n/a" when empty.
+type Class struct {
+	Name         string
+	TargetMarket string
+	AddFee       bool
+	MaxInitFee   string
+	TICDate      time.Time
+	TERPerfComp  string
+	TER          string
+	TC           string
+	TIC          string
+	PriceDate    time.Time
+	NAV          string
+	History      []Price
+}
+
+type Price struct {
+	Date time.Time
+	NAV  float64
+}
+
+// Server is a running fake site.
+type Server struct {
+	*httptest.Server
+
+	scenario  *Scenario
+	endpoints *scraper.Endpoints
+
+	mu         sync.Mutex
+	viewStates map[string]bool
+	issued     int
+	requests   map[string]int
+}
+
+// NewServer starts a fake site serving scenario at the default page paths and
+// form field names. Callers must Close it.
+func NewServer(scenario *Scenario) *Server {
+	return NewServerWithEndpoints(scenario, scraper.DefaultEndpoints())
+}
+
+// NewServerWithEndpoints is NewServer serving the page paths and form field
+// names of endpoints. Its base URL is ignored.
+func NewServerWithEndpoints(scenario *Scenario, endpoints *scraper.Endpoints) *Server {
+	s := &Server{
+		scenario:   scenario,
+		endpoints:  endpoints,
+		viewStates: make(map[string]bool),
+		requests:   make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+strings.TrimPrefix(endpoints.HistPriceLookUpPath, "/"), s.handleHistPriceLookUp)
+	mux.HandleFunc("/"+strings.TrimPrefix(endpoints.LatestPricesPath, "/"), s.handleLatestPrices)
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Endpoints returns the endpoints to scrape this server with.
+func (s *Server) Endpoints() *scraper.Endpoints {
+	endpoints := *s.endpoints
+	endpoints.BaseURL = s.URL
+	return &endpoints
+}
+
+// Requests returns how many requests were made for method and path, for
+// example Requests("GET", "/LatestPrices.aspx").
+func (s *Server) Requests(method string, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[method+" "+path]
+}
+
+func (s *Server) count(r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[r.Method+" "+r.URL.Path]++
+}
+
+// newViewState issues a ViewState value that later post-backs must echo, as
+// ASP.NET rejects post-backs with state it did not hand out.
+func (s *Server) newViewState() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.issued++
+	viewState := fmt.Sprintf("/wEPDwUKLTk%06dZGQ=", s.issued)
+	s.viewStates[viewState] = true
+	return viewState
+}
+
+func (s *Server) validViewState(viewState string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.viewStates[viewState]
+}
+
+func (s *Server) findManager(id int) *Manager {
+	for i := range s.scenario.Managers {
+		if s.scenario.Managers[i].ID == id {
+			return &s.scenario.Managers[i]
+		}
+	}
+	return nil
+}
+
+func (s *Server) handleHistPriceLookUp(w http.ResponseWriter, r *http.Request) {
+	s.count(r)
+
+	fields := s.endpoints.Fields
+	page := histPriceLookUpPage{Fields: fields, Managers: s.scenario.Managers}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if !s.validViewState(r.PostForm.Get("__VIEWSTATE")) {
+			http.Error(w, "Validation of viewstate MAC failed.", http.StatusInternalServerError)
+			return
+		}
+
+		mancoID, err := strconv.Atoi(r.PostForm.Get(fields.ManagerID))
+		if err != nil {
+			http.Error(w, "invalid "+fields.ManagerID, http.StatusInternalServerError)
+			return
+		}
+
+		manager := s.findManager(mancoID)
+		if manager == nil {
+			http.Error(w, "unknown "+fields.ManagerID, http.StatusInternalServerError)
+			return
+		}
+		page.Selected = manager
+
+		if trustNo := r.PostForm.Get(fields.TrustNo); trustNo != "" {
+			prices, err := historicalPrices(manager, trustNo, r.PostForm.Get(fields.StartDate), r.PostForm.Get(fields.EndDate))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			page.Prices = prices
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	page.ViewState = s.newViewState()
+	render(w, histPriceLookUpTemplate, page)
+}
+
+func (s *Server) handleLatestPrices(w http.ResponseWriter, r *http.Request) {
+	s.count(r)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var page latestPricesPage
+	for _, manager := range s.scenario.Managers {
+		block := latestPricesManager{Name: manager.Name}
+
+		bySector := make(map[string][]latestPricesRow)
+		var sectors []string
+		for _, fund := range manager.Funds {
+			if _, ok := bySector[fund.Category]; !ok {
+				sectors = append(sectors, fund.Category)
+			}
+
+			name := fund.ListedName
+			if name == "" {
+				name = fund.Name
+			}
+
+			for _, class := range fund.Classes {
+				bySector[fund.Category] = append(bySector[fund.Category], latestPricesRow{
+					Name:         name + " Class " + class.Name,
+					AddFee:       yesNo(class.AddFee),
+					TargetMarket: class.TargetMarket,
+					MaxInitFee:   orNA(class.MaxInitFee),
+					TICDate:      formatDate(class.TICDate),
+					TERPerfComp:  orNA(class.TERPerfComp),
+					TER:          orNA(class.TER),
+					TC:           orNA(class.TC),
+					TIC:          orNA(class.TIC),
+					PriceDate:    formatDate(class.PriceDate),
+					NAV:          orNA(class.NAV),
+				})
+			}
+		}
+
+		for _, sector := range sectors {
+			block.Sectors = append(block.Sectors, latestPricesSector{Name: sector, Rows: bySector[sector]})
+		}
+		page.Managers = append(page.Managers, block)
+	}
+
+	render(w, latestPricesTemplate, page)
+}
+
+// historicalPrices builds the Date by class table for one fund between the
+// posted dd/mm/yyyy dates.
+func historicalPrices(manager *Manager, trustNo string, startDate string, endDate string) (*priceTable, error) {
+	from, err := time.Parse("02/01/2006", startDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid StartDate %q", startDate)
+	}
+
+	to, err := time.Parse("02/01/2006", endDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EndDate %q", endDate)
+	}
+
+	var fund *Fund
+	for i := range manager.Funds {
+		if strconv.Itoa(manager.Funds[i].TrustNo) == trustNo {
+			fund = &manager.Funds[i]
+		}
+	}
+
+	if fund == nil {
+		return nil, fmt.Errorf("unknown TrustNo %q", trustNo)
+	}
+
+	table := &priceTable{}
+	navs := make(map[time.Time][]string)
+	for i, class := range fund.Classes {
+		table.Classes = append(table.Classes, class.Name)
+		for _, price := range class.History {
+			if price.Date.Before(from) || price.Date.After(to) {
+				continue
+			}
+			if _, ok := navs[price.Date]; !ok {
+				navs[price.Date] = make([]string, len(fund.Classes))
+			}
+			navs[price.Date][i] = strconv.FormatFloat(price.NAV, 'f', 2, 64)
+		}
+	}
+
+	dates := make([]time.Time, 0, len(navs))
+	for date := range navs {
+		dates = append(dates, date)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	for _, date := range dates {
+		row := priceRow{Date: formatDate(date)}
+		for _, nav := range navs[date] {
+			row.NAVs = append(row.NAVs, orNA(nav))
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	return table, nil
+}
+
+func render(w http.ResponseWriter, tmpl *template.Template, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return "n/a"
+	}
+	return date.Format("02/01/2006")
+}
+
+func orNA(value string) string {
+	if value == "" {
+		return "n/a"
+	}
+	return value
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,135 @@
+package asisatest
+
+import (
+	"fmt"
+	"html/template"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/scraper"
+)
+
+type histPriceLookUpPage struct {
+	Fields    scraper.FormFields
+	ViewState string
+	Managers  []Manager
+	Selected  *Manager
+	Prices    *priceTable
+}
+
+type priceTable struct {
+	Classes []string
+	Rows    []priceRow
+}
+
+type priceRow struct {
+	Date string
+	NAVs []string
+}
+
+type latestPricesPage struct {
+	Managers []latestPricesManager
+}
+
+type latestPricesManager struct {
+	Name    string
+	Sectors []latestPricesSector
+}
+
+type latestPricesSector struct {
+	Name string
+	Rows []latestPricesRow
+}
+
+type latestPricesRow struct {
+	Name         string
+	AddFee       string
+	TargetMarket string
+	MaxInitFee   string
+	TICDate      string
+	TERPerfComp  string
+	TER          string
+	TC           string
+	TIC          string
+	PriceDate    string
+	NAV          string
+}
+
+var histPriceLookUpTemplate = template.Must(template.New("HistPriceLookUp").Funcs(template.FuncMap{
+	"mancoID": func(id int) string { return fmt.Sprintf("%04d", id) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><title>ASISA - Historical Price Look Up</title></head>
+<body>
+<form method="post" action="./HistPriceLookUp.aspx" id="form1">
+<input type="hidden" name="__VIEWSTATE" id="__VIEWSTATE" value="{{.ViewState}}" />
+<input type="hidden" name="__VIEWSTATEGENERATOR" id="__VIEWSTATEGENERATOR" value="B7E7D2A1" />
+<input type="hidden" name="__EVENTVALIDATION" id="__EVENTVALIDATION" value="/wEdAAVb3W0fQpJfYx6kN2sQmZc1" />
+<select name="{{.Fields.ManagerID}}" id="{{.Fields.ManagerID}}">
+	<option value="">-- Select a Management Company --</option>
+{{- range .Managers}}
+	<option value="{{mancoID .ID}}"{{if and $.Selected (eq $.Selected.ID .ID)}} selected="selected"{{end}}>{{.Name}}</option>
+{{- end}}
+</select>
+{{- with .Selected}}
+<select name="{{$.Fields.TrustNo}}" id="{{$.Fields.TrustNo}}">
+	<option value="">-- Select a Fund --</option>
+{{- range .Funds}}
+	<option value="{{.TrustNo}}">{{.Name}}</option>
+{{- end}}
+</select>
+{{- end}}
+{{- with .Prices}}
+<table class="prices">
+	<tr><th>Date</th>{{range .Classes}}<th>{{.}}</th>{{end}}</tr>
+{{- range .Rows}}
+	<tr><td>{{.Date}}</td>{{range .NAVs}}<td>{{.}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{- end}}
+</form>
+</body>
+</html>
+`))
+
+var latestPricesTemplate = template.Must(template.New("LatestPrices").Parse(`<!DOCTYPE html>
+<html>
+<head><title>ASISA - Latest Prices</title></head>
+<body>
+<table id="dataTable" class="datatable">
+	<tr class="headerrow">
+		<th>Fund</th>
+		<th>Add Fee</th>
+		<th>Target Market</th>
+		<th>Max Init Fee (%)</th>
+		<th>TIC Date</th>
+		<th>TER Perf Comp (%)</th>
+		<th>TER (%)</th>
+		<th>TC (%)</th>
+		<th>TIC (%)</th>
+		<th>Price Date</th>
+		<th>NAV</th>
+	</tr>
+{{- range .Managers}}
+	<tr class="mancorow"><td colspan="11">{{.Name}}</td></tr>
+{{- range .Sectors}}
+	<tr class="sectorrow"><td colspan="11">{{.Name}}</td></tr>
+{{- range .Rows}}
+	<tr class="fundrow">
+		<td><div class="fundname">{{.Name}}</div></td>
+		<td>{{.AddFee}}</td>
+		<td>{{.TargetMarket}}</td>
+		<td>{{.MaxInitFee}}</td>
+		<td>{{.TICDate}}</td>
+		<td>{{.TERPerfComp}}</td>
+		<td>{{.TER}}</td>
+		<td>{{.TC}}</td>
+		<td>{{.TIC}}</td>
+		<td>{{.PriceDate}}</td>
+		<td>{{.NAV}}</td>
+	</tr>
+{{- end}}
+{{- end}}
+{{- end}}
+</table>
+</body>
+</html>
+`))
@@ -1,15 +1,20 @@
 package database
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
 )
 
 func (db *DB) GetAllCISManagers() ([]*models.CISManager, error) {
+	return db.GetAllCISManagersContext(context.Background())
+}
+
+func (db *DB) GetAllCISManagersContext(ctx context.Context) ([]*models.CISManager, error) {
 	var cisMangers []*models.CISManager
 
-	err := db.conn.Select(&cisMangers, "SELECT * FROM cisManagers")
+	err := db.conn.SelectContext(ctx, &cisMangers, "SELECT * FROM cisManagers")
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to select all cisManagers: %w", err)
@@ -19,13 +24,34 @@ func (db *DB) GetAllCISManagers() ([]*models.CISManager, error) {
 }
 
 func (db *DB) SaveCISManagers(cisManager []*models.CISManager) error {
+	return db.SaveCISManagersContext(context.Background(), cisManager)
+}
+
+func (db *DB) SaveCISManagersContext(ctx context.Context, cisManager []*models.CISManager) error {
 	query := `
 		INSERT INTO cisManagers (id, name)
 		VALUES (:id, :name)
 		ON CONFLICT (id) DO UPDATE
 		SET name = EXCLUDED.name
 	`
-	_, err := db.conn.NamedExec(query, cisManager)
+	_, err := db.conn.NamedExecContext(ctx, query, cisManager)
 
 	return err
 }
+
+func (db *DB) ListCISManagersContext(ctx context.Context, page Page) ([]*models.CISManager, int, error) {
+	var total int
+	if err := db.conn.GetContext(ctx, &total, "SELECT COUNT(*) FROM cisManagers"); err != nil {
+		return nil, 0, fmt.Errorf("error counting cisManagers: %w", err)
+	}
+
+	clause, args := db.pageClause(page)
+	query := db.conn.Rebind("SELECT id, name FROM cisManagers ORDER BY id" + clause)
+
+	var cisManagers []*models.CISManager
+	if err := db.conn.SelectContext(ctx, &cisManagers, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("error listing cisManagers: %w", err)
+	}
+
+	return cisManagers, total, nil
+}
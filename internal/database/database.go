@@ -2,17 +2,27 @@ package database
 
 import (
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 
 	_ "github.com/lib/pq"
 )
 
+const postgresDriver = "postgres"
+
 type DB struct {
-	conn *sqlx.DB
+	conn   *sqlx.DB
+	driver string
 }
 
+// DbConfig describes the database to connect to. When DSN is set its scheme
+// selects the backend (postgres:// or sqlite://) and the other fields are
+// ignored.
 type DbConfig struct {
+	DSN      string
 	Host     string
 	Port     int
 	User     string
@@ -21,22 +31,75 @@ type DbConfig struct {
 	SSLMode  string
 }
 
-func NewDB(config *DbConfig) (*DB, error) {
+// ConfigFromEnv reads DB_DSN, or when it is unset the DB_HOST, DB_PORT,
+// DB_USER, DB_PASSWORD, DB_NAME and SSLMode variables.
+func ConfigFromEnv() (*DbConfig, error) {
+	if dsn := os.Getenv("DB_DSN"); dsn != "" {
+		return &DbConfig{DSN: dsn}, nil
+	}
+
+	port, err := strconv.Atoi(os.Getenv("DB_PORT"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database port")
+	}
 
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
-		config.User, config.Password, config.Host, config.Port, config.DBName, config.SSLMode)
+	return &DbConfig{
+		Host:     os.Getenv("DB_HOST"),
+		Port:     port,
+		User:     os.Getenv("DB_USER"),
+		Password: os.Getenv("DB_PASSWORD"),
+		DBName:   os.Getenv("DB_NAME"),
+		SSLMode:  os.Getenv("SSLMode"),
+	}, nil
+}
+
+func NewDB(config *DbConfig) (*DB, error) {
+	driver, connStr, err := config.dataSource()
+	if err != nil {
+		return nil, err
+	}
 
-	conn, err := sqlx.Open("postgres", connStr)
+	conn, err := sqlx.Open(driver, connStr)
 
 	if err != nil {
 		return nil, fmt.Errorf("error opening database connection: %s", err)
 	}
 
+	if driver == sqliteDriver {
+		// SQLite only allows a single writer, so serialise access through one connection.
+		conn.SetMaxOpenConns(1)
+	}
+
 	if err := conn.Ping(); err != nil {
 		return nil, fmt.Errorf("error ping'ing database %s", err)
 	}
 
-	return &DB{conn: conn}, nil
+	return &DB{conn: conn, driver: driver}, nil
+}
+
+func (config *DbConfig) dataSource() (string, string, error) {
+	if config.DSN == "" {
+		return postgresDriver, fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
+			config.User, config.Password, config.Host, config.Port, config.DBName, config.SSLMode), nil
+	}
+
+	scheme, _, ok := strings.Cut(config.DSN, ":")
+	if !ok {
+		return "", "", fmt.Errorf("database DSN has no scheme: %s", config.DSN)
+	}
+
+	switch scheme {
+	case "postgres", "postgresql":
+		return postgresDriver, config.DSN, nil
+	case "sqlite", "sqlite3", "file":
+		return sqliteDriver, sqliteDataSource(config.DSN), nil
+	default:
+		return "", "", fmt.Errorf("unsupported database scheme %q", scheme)
+	}
+}
+
+func (db *DB) Driver() string {
+	return db.driver
 }
 
 func (db *DB) Close() error {
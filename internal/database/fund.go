@@ -1,8 +1,17 @@
 package database
 
-import "github.com/Alexandervanderleek/FundFinderZA/internal/models"
+import (
+	"context"
+	"fmt"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
 
 func (db *DB) SaveFunds(funds []*models.Fund) error {
+	return db.SaveFundsContext(context.Background(), funds)
+}
+
+func (db *DB) SaveFundsContext(ctx context.Context, funds []*models.Fund) error {
 
 	query := `
 		INSERT INTO funds (trust_no, name, secondary_name, manager_id)
@@ -13,8 +22,69 @@ func (db *DB) SaveFunds(funds []*models.Fund) error {
 			manager_id = EXCLUDED.manager_id
 		`
 
-	_, err := db.conn.NamedExec(query, funds)
+	_, err := db.conn.NamedExecContext(ctx, query, funds)
 
 	return err
 
 }
+
+func (db *DB) GetAllFunds() ([]*models.Fund, error) {
+	return db.GetAllFundsContext(context.Background())
+}
+
+func (db *DB) GetAllFundsContext(ctx context.Context) ([]*models.Fund, error) {
+	var funds []*models.Fund
+
+	err := db.conn.SelectContext(ctx, &funds, "SELECT trust_no, name, COALESCE(secondary_name, '') AS secondary_name, manager_id FROM funds")
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to select all funds: %w", err)
+	}
+
+	return funds, nil
+}
+
+// ListFundsContext lists the funds of one manager, or of every manager when
+// managerID is 0, ordered by trust number.
+func (db *DB) ListFundsContext(ctx context.Context, managerID int, page Page) ([]*models.Fund, int, error) {
+	var total int
+	countQuery := db.conn.Rebind("SELECT COUNT(*) FROM funds WHERE ? = 0 OR manager_id = ?")
+	if err := db.conn.GetContext(ctx, &total, countQuery, managerID, managerID); err != nil {
+		return nil, 0, fmt.Errorf("error counting funds: %w", err)
+	}
+
+	clause, args := db.pageClause(page)
+	query := db.conn.Rebind(`
+		SELECT trust_no, name, COALESCE(secondary_name, '') AS secondary_name, manager_id
+		FROM funds
+		WHERE ? = 0 OR manager_id = ?
+		ORDER BY trust_no` + clause)
+
+	var funds []*models.Fund
+	if err := db.conn.SelectContext(ctx, &funds, query, append([]any{managerID, managerID}, args...)...); err != nil {
+		return nil, 0, fmt.Errorf("error listing funds: %w", err)
+	}
+
+	return funds, total, nil
+}
+
+// GetFundContext returns nil when there is no fund with the trust number.
+func (db *DB) GetFundContext(ctx context.Context, trustNo int) (*models.Fund, error) {
+	var funds []*models.Fund
+
+	query := `
+		SELECT trust_no, name, COALESCE(secondary_name, '') AS secondary_name, manager_id
+		FROM funds
+		WHERE trust_no = $1
+	`
+
+	if err := db.conn.SelectContext(ctx, &funds, query, trustNo); err != nil {
+		return nil, fmt.Errorf("error getting fund %d: %w", trustNo, err)
+	}
+
+	if len(funds) == 0 {
+		return nil, nil
+	}
+
+	return funds[0], nil
+}
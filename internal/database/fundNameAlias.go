@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+const (
+	AliasStatusProposed  = "proposed"
+	AliasStatusConfirmed = "confirmed"
+	AliasStatusRejected  = "rejected"
+)
+
+const (
+	AliasSourceFuzzy  = "fuzzy"
+	AliasSourceManual = "manual"
+)
+
+// GetFundNameAliasesContext returns every alias recorded for a scraped name,
+// whatever its status.
+func (db *DB) GetFundNameAliasesContext(ctx context.Context, scrapedName string) ([]*models.FundNameAlias, error) {
+	var aliases []*models.FundNameAlias
+
+	query := `
+		SELECT id, scraped_name, trust_no, source, status, confirmed_by, created_at, updated_at
+		FROM fund_name_aliases
+		WHERE scraped_name = $1
+		ORDER BY id
+	`
+
+	err := db.conn.SelectContext(ctx, &aliases, query, scrapedName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting aliases for %s: %w", scrapedName, err)
+	}
+
+	return aliases, nil
+}
+
+// ListFundNameAliasesContext lists aliases with the given status, or all
+// aliases when status is empty.
+func (db *DB) ListFundNameAliasesContext(ctx context.Context, status string) ([]*models.FundNameAlias, error) {
+	var aliases []*models.FundNameAlias
+
+	query := `
+		SELECT id, scraped_name, trust_no, source, status, confirmed_by, created_at, updated_at
+		FROM fund_name_aliases
+		WHERE $1 = '' OR status = $1
+		ORDER BY scraped_name, id
+	`
+
+	err := db.conn.SelectContext(ctx, &aliases, query, status)
+	if err != nil {
+		return nil, fmt.Errorf("error listing aliases: %w", err)
+	}
+
+	return aliases, nil
+}
+
+// ProposeFundNameAliasContext records a mapping for review. An existing alias
+// for the same pair keeps its status, so confirmed and rejected decisions stick.
+func (db *DB) ProposeFundNameAliasContext(ctx context.Context, scrapedName string, trustNo int, source string) error {
+	query := `
+		INSERT INTO fund_name_aliases (scraped_name, trust_no, source, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (scraped_name, trust_no) DO NOTHING
+	`
+
+	_, err := db.conn.ExecContext(ctx, query, scrapedName, trustNo, source, AliasStatusProposed, time.Now().UTC())
+
+	return err
+}
+
+// SetFundNameAliasStatusContext confirms or rejects a mapping, creating it as a
+// manual alias when it was never proposed. Confirming a mapping rejects any
+// other confirmed mapping for the same scraped name.
+func (db *DB) SetFundNameAliasStatusContext(ctx context.Context, scrapedName string, trustNo int, status string, confirmedBy string) error {
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+
+	if status == AliasStatusConfirmed {
+		demote := `
+			UPDATE fund_name_aliases
+			SET status = $1, confirmed_by = $2, updated_at = $3
+			WHERE scraped_name = $4 AND trust_no <> $5 AND status = $6
+		`
+
+		if _, err := tx.ExecContext(ctx, demote, AliasStatusRejected, confirmedBy, now, scrapedName, trustNo, AliasStatusConfirmed); err != nil {
+			return err
+		}
+	}
+
+	upsert := `
+		INSERT INTO fund_name_aliases (scraped_name, trust_no, source, status, confirmed_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (scraped_name, trust_no) DO UPDATE
+		SET status = EXCLUDED.status,
+			confirmed_by = EXCLUDED.confirmed_by,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := tx.ExecContext(ctx, upsert, scrapedName, trustNo, AliasSourceManual, status, confirmedBy, now); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
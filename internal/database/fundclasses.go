@@ -1,12 +1,17 @@
 package database
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
 )
 
 func (db *DB) SaveFundClass(fundClass *models.FundClass) error {
+	return db.SaveFundClassContext(context.Background(), fundClass)
+}
+
+func (db *DB) SaveFundClassContext(ctx context.Context, fundClass *models.FundClass) error {
 	query := `
 		INSERT INTO fund_classes (fund_id, class_name, target_market, add_fee, max_init_fee, category)
 		VALUES (:fund_id, :class_name, :target_market, :add_fee, :max_init_fee, :category)
@@ -18,7 +23,7 @@ func (db *DB) SaveFundClass(fundClass *models.FundClass) error {
 		RETURNING id
 	`
 
-	rows, err := db.conn.NamedQuery(query, fundClass)
+	rows, err := db.conn.NamedQueryContext(ctx, query, fundClass)
 	if err != nil {
 		return err
 	}
@@ -34,6 +39,17 @@ func (db *DB) SaveFundClass(fundClass *models.FundClass) error {
 }
 
 func (db *DB) SaveFundClassCosts(fundClassCost *models.FundClassCost) error {
+	return db.SaveFundClassCostsContext(context.Background(), fundClassCost)
+}
+
+// SaveFundClassCostsContext upserts the costs of a fund class for their TIC
+// date. Costs without one are rejected: the unique key would not catch a
+// repeat of them, and no query could tell which is current.
+func (db *DB) SaveFundClassCostsContext(ctx context.Context, fundClassCost *models.FundClassCost) error {
+	if fundClassCost.TICDate == nil {
+		return fmt.Errorf("costs of fund class %d have no TIC date", fundClassCost.FundClassID)
+	}
+
 	query := `
 		INSERT INTO fund_class_costs (fund_class_id, tic_date, ter_perf_comp, ter, tc, tic)
 		VALUES (:fund_class_id, :tic_date, :ter_perf_comp, :ter, :tc, :tic)
@@ -44,24 +60,32 @@ func (db *DB) SaveFundClassCosts(fundClassCost *models.FundClassCost) error {
 			tic = EXCLUDED.tic
 	`
 
-	_, err := db.conn.NamedExec(query, fundClassCost)
+	_, err := db.conn.NamedExecContext(ctx, query, fundClassCost)
 
 	return err
 }
 
 func (db *DB) SaveFundClassPrice(fundClassPrice *models.FundClassPrice) error {
+	return db.SaveFundClassPriceContext(context.Background(), fundClassPrice)
+}
+
+func (db *DB) SaveFundClassPriceContext(ctx context.Context, fundClassPrice *models.FundClassPrice) error {
 	query := `
 		INSERT INTO fund_class_prices (fund_class_id, price_date, nav)
 		VALUES (:fund_class_id, :price_date, :nav)
 		ON CONFLICT (fund_class_id, price_date) DO UPDATE
 		SET nav = EXCLUDED.nav
 	`
-	_, err := db.conn.NamedExec(query, fundClassPrice)
+	_, err := db.conn.NamedExecContext(ctx, query, fundClassPrice)
 
 	return err
 }
 
 func (db *DB) GetFundByName(name string) (int, error) {
+	return db.GetFundByNameContext(context.Background(), name)
+}
+
+func (db *DB) GetFundByNameContext(ctx context.Context, name string) (int, error) {
 	var fundID int
 
 	query := `
@@ -71,7 +95,7 @@ func (db *DB) GetFundByName(name string) (int, error) {
 		LIMIT 1
 	`
 
-	err := db.conn.Get(&fundID, query, name)
+	err := db.conn.GetContext(ctx, &fundID, query, name)
 	if err != nil {
 		if err.Error() == "sql: no rows in result set" {
 			return 0, nil
@@ -83,9 +107,13 @@ func (db *DB) GetFundByName(name string) (int, error) {
 }
 
 func (db *DB) GetAllFundNames() (map[int]string, error) {
+	return db.GetAllFundNamesContext(context.Background())
+}
+
+func (db *DB) GetAllFundNamesContext(ctx context.Context) (map[int]string, error) {
 	query := `SELECT trust_no, name FROM funds`
 
-	rows, err := db.conn.Query(query)
+	rows, err := db.conn.QueryContext(ctx, query)
 
 	if err != nil {
 		return nil, fmt.Errorf("error getting fund names: %s", err)
@@ -106,3 +134,161 @@ func (db *DB) GetAllFundNames() (map[int]string, error) {
 
 	return fundNames, nil
 }
+
+func (db *DB) GetFundClassesByFund(fundID int) ([]*models.FundClass, error) {
+	return db.GetFundClassesByFundContext(context.Background(), fundID)
+}
+
+func (db *DB) GetFundClassesByFundContext(ctx context.Context, fundID int) ([]*models.FundClass, error) {
+	var fundClasses []*models.FundClass
+
+	query := `
+		SELECT id, fund_id, class_name, COALESCE(add_fee, false) AS add_fee, max_init_fee,
+			COALESCE(category, '') AS category, COALESCE(CAST(target_market AS TEXT), '') AS target_market
+		FROM fund_classes
+		WHERE fund_id = $1
+	`
+
+	err := db.conn.SelectContext(ctx, &fundClasses, query, fundID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting fund classes for fund %d: %w", fundID, err)
+	}
+
+	return fundClasses, nil
+}
+
+// GetFundClassContext returns nil when there is no fund class with the id.
+func (db *DB) GetFundClassContext(ctx context.Context, id int) (*models.FundClass, error) {
+	var fundClasses []*models.FundClass
+
+	query := `
+		SELECT id, fund_id, class_name, COALESCE(add_fee, false) AS add_fee, max_init_fee,
+			COALESCE(category, '') AS category, COALESCE(CAST(target_market AS TEXT), '') AS target_market
+		FROM fund_classes
+		WHERE id = $1
+	`
+
+	if err := db.conn.SelectContext(ctx, &fundClasses, query, id); err != nil {
+		return nil, fmt.Errorf("error getting fund class %d: %w", id, err)
+	}
+
+	if len(fundClasses) == 0 {
+		return nil, nil
+	}
+
+	return fundClasses[0], nil
+}
+
+// GetLatestFundClassCostsContext returns the most recent costs of each class
+// of the fund, keyed by fund class id. Classes without costs are left out.
+func (db *DB) GetLatestFundClassCostsContext(ctx context.Context, fundID int) (map[int]*models.FundClassCost, error) {
+	var costs []*models.FundClassCost
+
+	query := `
+		SELECT c.id, c.fund_class_id, c.tic_date, c.ter_perf_comp, c.ter, c.tc, c.tic
+		FROM fund_class_costs c
+		JOIN fund_classes fc ON fc.id = c.fund_class_id
+		WHERE fc.fund_id = $1
+			AND c.tic_date = (
+				SELECT MAX(latest.tic_date)
+				FROM fund_class_costs latest
+				WHERE latest.fund_class_id = c.fund_class_id
+			)
+	`
+
+	if err := db.conn.SelectContext(ctx, &costs, query, fundID); err != nil {
+		return nil, fmt.Errorf("error getting latest costs for fund %d: %w", fundID, err)
+	}
+
+	latest := make(map[int]*models.FundClassCost, len(costs))
+	for _, cost := range costs {
+		latest[cost.FundClassID] = cost
+	}
+
+	return latest, nil
+}
+
+// GetFundClassCostsAsOfContext returns the costs of a fund class that were
+// current on asOf, the latest with a TIC date on or before it, or nil when
+// there are none.
+func (db *DB) GetFundClassCostsAsOfContext(ctx context.Context, fundClassID int, asOf models.Date) (*models.FundClassCost, error) {
+	var costs []*models.FundClassCost
+
+	query := `
+		SELECT id, fund_class_id, tic_date, ter_perf_comp, ter, tc, tic
+		FROM fund_class_costs
+		WHERE fund_class_id = $1 AND tic_date <= $2
+		ORDER BY tic_date DESC
+		LIMIT 1
+	`
+
+	if err := db.conn.SelectContext(ctx, &costs, query, fundClassID, asOf); err != nil {
+		return nil, fmt.Errorf("error getting costs of fund class %d as of %s: %w", fundClassID, asOf, err)
+	}
+
+	if len(costs) == 0 {
+		return nil, nil
+	}
+
+	return costs[0], nil
+}
+
+// GetLatestFundClassPricesContext returns the most recent price of each class
+// of the fund, keyed by fund class id. Classes without prices are left out.
+func (db *DB) GetLatestFundClassPricesContext(ctx context.Context, fundID int) (map[int]*models.FundClassPrice, error) {
+	var prices []*models.FundClassPrice
+
+	query := `
+		SELECT p.id, p.fund_class_id, p.price_date, p.nav
+		FROM fund_class_prices p
+		JOIN fund_classes fc ON fc.id = p.fund_class_id
+		WHERE fc.fund_id = $1
+			AND p.price_date = (
+				SELECT MAX(latest.price_date)
+				FROM fund_class_prices latest
+				WHERE latest.fund_class_id = p.fund_class_id
+			)
+	`
+
+	if err := db.conn.SelectContext(ctx, &prices, query, fundID); err != nil {
+		return nil, fmt.Errorf("error getting latest prices for fund %d: %w", fundID, err)
+	}
+
+	latest := make(map[int]*models.FundClassPrice, len(prices))
+	for _, price := range prices {
+		latest[price.FundClassID] = price
+	}
+
+	return latest, nil
+}
+
+// ListFundClassPricesContext lists the prices of a fund class oldest first,
+// limited to from and to when they are set.
+func (db *DB) ListFundClassPricesContext(ctx context.Context, fundClassID int, from *models.Date, to *models.Date, page Page) ([]*models.FundClassPrice, int, error) {
+	where := " WHERE fund_class_id = ?"
+	args := []any{fundClassID}
+	if from != nil {
+		where += " AND price_date >= ?"
+		args = append(args, *from)
+	}
+	if to != nil {
+		where += " AND price_date <= ?"
+		args = append(args, *to)
+	}
+
+	var total int
+	countQuery := db.conn.Rebind("SELECT COUNT(*) FROM fund_class_prices" + where)
+	if err := db.conn.GetContext(ctx, &total, countQuery, args...); err != nil {
+		return nil, 0, fmt.Errorf("error counting prices for fund class %d: %w", fundClassID, err)
+	}
+
+	clause, pageArgs := db.pageClause(page)
+	query := db.conn.Rebind("SELECT id, fund_class_id, price_date, nav FROM fund_class_prices" + where + " ORDER BY price_date" + clause)
+
+	var prices []*models.FundClassPrice
+	if err := db.conn.SelectContext(ctx, &prices, query, append(args, pageArgs...)...); err != nil {
+		return nil, 0, fmt.Errorf("error listing prices for fund class %d: %w", fundClassID, err)
+	}
+
+	return prices, total, nil
+}
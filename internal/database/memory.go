@@ -0,0 +1,771 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+type fundClassKey struct {
+	fundID    int
+	className string
+}
+
+type fundClassDateKey struct {
+	fundClassID int
+	date        models.Date
+}
+
+// MemoryStore is an in-memory Store with the same upsert semantics as DB,
+// intended for tests and dry runs.
+type MemoryStore struct {
+	mu sync.Mutex
+
+	managers    map[int]models.CISManager
+	funds       map[int]models.Fund
+	fundClasses map[int]models.FundClass
+	classIDs    map[fundClassKey]int
+	costs       map[fundClassDateKey]models.FundClassCost
+	prices      map[fundClassDateKey]models.FundClassPrice
+	nextID      int
+
+	runs        []models.ScrapeRun
+	runItems    []models.ScrapeRunItem
+	checkpoints map[int][]string
+	nextRunID   int
+
+	aliases []models.FundNameAlias
+
+	peerRankings  []models.PeerRanking
+	nextRankingID int
+}
+
+var (
+	_ Store     = (*MemoryStore)(nil)
+	_ ReadStore = (*MemoryStore)(nil)
+)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		managers:    make(map[int]models.CISManager),
+		funds:       make(map[int]models.Fund),
+		fundClasses: make(map[int]models.FundClass),
+		classIDs:    make(map[fundClassKey]int),
+		costs:       make(map[fundClassDateKey]models.FundClassCost),
+		prices:      make(map[fundClassDateKey]models.FundClassPrice),
+		checkpoints: make(map[int][]string),
+	}
+}
+
+func (m *MemoryStore) GetAllCISManagersContext(ctx context.Context) ([]*models.CISManager, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	managers := make([]*models.CISManager, 0, len(m.managers))
+	for _, manager := range m.managers {
+		managers = append(managers, &manager)
+	}
+
+	sort.Slice(managers, func(i, j int) bool {
+		return managers[i].ID < managers[j].ID
+	})
+
+	return managers, nil
+}
+
+func (m *MemoryStore) SaveCISManagersContext(ctx context.Context, cisManager []*models.CISManager) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, manager := range cisManager {
+		m.managers[manager.ID] = *manager
+	}
+
+	return nil
+}
+
+func (m *MemoryStore) GetAllFundsContext(ctx context.Context) ([]*models.Fund, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	funds := make([]*models.Fund, 0, len(m.funds))
+	for _, fund := range m.funds {
+		funds = append(funds, &fund)
+	}
+
+	sort.Slice(funds, func(i, j int) bool {
+		return funds[i].TrustNo < funds[j].TrustNo
+	})
+
+	return funds, nil
+}
+
+func (m *MemoryStore) SaveFundsContext(ctx context.Context, funds []*models.Fund) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, fund := range funds {
+		if _, exists := m.managers[fund.ManagerID]; !exists {
+			return fmt.Errorf("fund %d references unknown manager %d", fund.TrustNo, fund.ManagerID)
+		}
+	}
+
+	for _, fund := range funds {
+		m.funds[fund.TrustNo] = *fund
+	}
+
+	return nil
+}
+
+func (m *MemoryStore) GetFundByNameContext(ctx context.Context, name string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.findFund(func(fund models.Fund) bool { return fund.Name == name }), nil
+}
+
+func (m *MemoryStore) GetAllFundNamesContext(ctx context.Context) (map[int]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fundNames := make(map[int]string, len(m.funds))
+	for trustNo, fund := range m.funds {
+		fundNames[trustNo] = fund.Name
+	}
+
+	return fundNames, nil
+}
+
+func (m *MemoryStore) findFund(match func(models.Fund) bool) int {
+	found := 0
+	for trustNo, fund := range m.funds {
+		if match(fund) && (found == 0 || trustNo < found) {
+			found = trustNo
+		}
+	}
+	return found
+}
+
+func (m *MemoryStore) GetFundClassesByFundContext(ctx context.Context, fundID int) ([]*models.FundClass, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var fundClasses []*models.FundClass
+	for _, fundClass := range m.fundClasses {
+		if fundClass.FundID == fundID {
+			fundClasses = append(fundClasses, &fundClass)
+		}
+	}
+
+	sort.Slice(fundClasses, func(i, j int) bool {
+		return fundClasses[i].ID < fundClasses[j].ID
+	})
+
+	return fundClasses, nil
+}
+
+func (m *MemoryStore) SaveFundClassContext(ctx context.Context, fundClass *models.FundClass) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.funds[fundClass.FundID]; !exists {
+		return fmt.Errorf("fund class %s references unknown fund %d", fundClass.ClassName, fundClass.FundID)
+	}
+
+	key := fundClassKey{fundID: fundClass.FundID, className: fundClass.ClassName}
+	id, exists := m.classIDs[key]
+	if !exists {
+		m.nextID++
+		id = m.nextID
+		m.classIDs[key] = id
+	}
+
+	fundClass.ID = id
+	stored := *fundClass
+	stored.FundName = ""
+	m.fundClasses[id] = stored
+
+	return nil
+}
+
+func (m *MemoryStore) SaveFundClassCostsContext(ctx context.Context, fundClassCost *models.FundClassCost) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.fundClasses[fundClassCost.FundClassID]; !exists {
+		return fmt.Errorf("costs reference unknown fund class %d", fundClassCost.FundClassID)
+	}
+
+	if fundClassCost.TICDate == nil {
+		return fmt.Errorf("costs of fund class %d have no TIC date", fundClassCost.FundClassID)
+	}
+
+	m.costs[fundClassDateKey{fundClassID: fundClassCost.FundClassID, date: *fundClassCost.TICDate}] = *fundClassCost
+
+	return nil
+}
+
+func (m *MemoryStore) SaveFundClassPriceContext(ctx context.Context, fundClassPrice *models.FundClassPrice) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.fundClasses[fundClassPrice.FundClassID]; !exists {
+		return fmt.Errorf("price references unknown fund class %d", fundClassPrice.FundClassID)
+	}
+
+	// price_date is NOT NULL in the database.
+	if fundClassPrice.PriceDate == nil {
+		return fmt.Errorf("price of fund class %d has no date", fundClassPrice.FundClassID)
+	}
+
+	m.prices[fundClassDateKey{fundClassID: fundClassPrice.FundClassID, date: *fundClassPrice.PriceDate}] = *fundClassPrice
+
+	return nil
+}
+
+func (m *MemoryStore) ListCISManagersContext(ctx context.Context, page Page) ([]*models.CISManager, int, error) {
+	managers, err := m.GetAllCISManagersContext(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	start, end := page.window(len(managers))
+	return managers[start:end], len(managers), nil
+}
+
+func (m *MemoryStore) ListFundsContext(ctx context.Context, managerID int, page Page) ([]*models.Fund, int, error) {
+	funds, err := m.GetAllFundsContext(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if managerID != 0 {
+		funds = slices.DeleteFunc(funds, func(fund *models.Fund) bool { return fund.ManagerID != managerID })
+	}
+
+	start, end := page.window(len(funds))
+	return funds[start:end], len(funds), nil
+}
+
+func (m *MemoryStore) GetFundContext(ctx context.Context, trustNo int) (*models.Fund, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fund, exists := m.funds[trustNo]
+	if !exists {
+		return nil, nil
+	}
+
+	return &fund, nil
+}
+
+func (m *MemoryStore) GetFundClassContext(ctx context.Context, id int) (*models.FundClass, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fundClass, exists := m.fundClasses[id]
+	if !exists {
+		return nil, nil
+	}
+
+	return &fundClass, nil
+}
+
+func (m *MemoryStore) GetLatestFundClassCostsContext(ctx context.Context, fundID int) (map[int]*models.FundClassCost, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	latest := make(map[int]*models.FundClassCost)
+	for key, cost := range m.costs {
+		if cost.TICDate == nil || m.fundClasses[key.fundClassID].FundID != fundID {
+			continue
+		}
+
+		if current, ok := latest[key.fundClassID]; !ok || cost.TICDate.After(*current.TICDate) {
+			latest[key.fundClassID] = &cost
+		}
+	}
+
+	return latest, nil
+}
+
+func (m *MemoryStore) GetFundClassCostsAsOfContext(ctx context.Context, fundClassID int, asOf models.Date) (*models.FundClassCost, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var current *models.FundClassCost
+	for key, cost := range m.costs {
+		if key.fundClassID != fundClassID || cost.TICDate == nil || cost.TICDate.After(asOf) {
+			continue
+		}
+
+		if current == nil || cost.TICDate.After(*current.TICDate) {
+			current = &cost
+		}
+	}
+
+	return current, nil
+}
+
+func (m *MemoryStore) GetLatestFundClassPricesContext(ctx context.Context, fundID int) (map[int]*models.FundClassPrice, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	latest := make(map[int]*models.FundClassPrice)
+	for key, price := range m.prices {
+		if price.PriceDate == nil || m.fundClasses[key.fundClassID].FundID != fundID {
+			continue
+		}
+
+		if current, ok := latest[key.fundClassID]; !ok || price.PriceDate.After(*current.PriceDate) {
+			latest[key.fundClassID] = &price
+		}
+	}
+
+	return latest, nil
+}
+
+func (m *MemoryStore) ListFundClassPricesContext(ctx context.Context, fundClassID int, from *models.Date, to *models.Date, page Page) ([]*models.FundClassPrice, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var prices []*models.FundClassPrice
+	for key, price := range m.prices {
+		if key.fundClassID != fundClassID || price.PriceDate == nil {
+			continue
+		}
+		if (from != nil && key.date.Before(*from)) || (to != nil && key.date.After(*to)) {
+			continue
+		}
+		prices = append(prices, &price)
+	}
+
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].PriceDate.Before(*prices[j].PriceDate)
+	})
+
+	start, end := page.window(len(prices))
+	return prices[start:end], len(prices), nil
+}
+
+func (m *MemoryStore) CreateScrapeRunContext(ctx context.Context, run *models.ScrapeRun) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextRunID++
+	run.ID = m.nextRunID
+	m.runs = append(m.runs, *run)
+
+	return nil
+}
+
+func (m *MemoryStore) FinishScrapeRunContext(ctx context.Context, run *models.ScrapeRun) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored := m.findRun(run.ID); stored != nil {
+		*stored = *run
+	}
+
+	return nil
+}
+
+func (m *MemoryStore) SaveScrapeRunItemContext(ctx context.Context, item *models.ScrapeRunItem) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.findRun(item.RunID) == nil {
+		return fmt.Errorf("run item references unknown run %d", item.RunID)
+	}
+
+	item.ID = len(m.runItems) + 1
+	m.runItems = append(m.runItems, *item)
+
+	return nil
+}
+
+func (m *MemoryStore) ListScrapeRunsContext(ctx context.Context, limit int) ([]*models.ScrapeRun, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var runs []*models.ScrapeRun
+	for i := len(m.runs) - 1; i >= 0 && len(runs) < limit; i-- {
+		run := m.runs[i]
+		runs = append(runs, &run)
+	}
+
+	return runs, nil
+}
+
+func (m *MemoryStore) GetScrapeRunContext(ctx context.Context, id int) (*models.ScrapeRun, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	run := m.findRun(id)
+	if run == nil {
+		return nil, nil
+	}
+
+	found := *run
+	return &found, nil
+}
+
+func (m *MemoryStore) GetScrapeRunItemsContext(ctx context.Context, runID int) ([]*models.ScrapeRunItem, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var items []*models.ScrapeRunItem
+	for _, item := range m.runItems {
+		if item.RunID == runID {
+			items = append(items, &item)
+		}
+	}
+
+	return items, nil
+}
+
+func (m *MemoryStore) GetLatestScrapeRunContext(ctx context.Context, mode string) (*models.ScrapeRun, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.runs) - 1; i >= 0; i-- {
+		if m.runs[i].Mode == mode {
+			run := m.runs[i]
+			return &run, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (m *MemoryStore) SaveScrapeRunCheckpointContext(ctx context.Context, runID int, itemKey string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.findRun(runID) == nil {
+		return fmt.Errorf("checkpoint references unknown run %d", runID)
+	}
+
+	if !slices.Contains(m.checkpoints[runID], itemKey) {
+		m.checkpoints[runID] = append(m.checkpoints[runID], itemKey)
+	}
+
+	return nil
+}
+
+func (m *MemoryStore) GetScrapeRunCheckpointsContext(ctx context.Context, runID int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.checkpoints[runID]), nil
+}
+
+func (m *MemoryStore) findRun(id int) *models.ScrapeRun {
+	for i := range m.runs {
+		if m.runs[i].ID == id {
+			return &m.runs[i]
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) GetFundNameAliasesContext(ctx context.Context, scrapedName string) ([]*models.FundNameAlias, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var aliases []*models.FundNameAlias
+	for _, alias := range m.aliases {
+		if alias.ScrapedName == scrapedName {
+			aliases = append(aliases, &alias)
+		}
+	}
+
+	return aliases, nil
+}
+
+func (m *MemoryStore) ListFundNameAliasesContext(ctx context.Context, status string) ([]*models.FundNameAlias, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var aliases []*models.FundNameAlias
+	for _, alias := range m.aliases {
+		if status == "" || alias.Status == status {
+			aliases = append(aliases, &alias)
+		}
+	}
+
+	sort.SliceStable(aliases, func(i, j int) bool {
+		return aliases[i].ScrapedName < aliases[j].ScrapedName
+	})
+
+	return aliases, nil
+}
+
+func (m *MemoryStore) ProposeFundNameAliasContext(ctx context.Context, scrapedName string, trustNo int, source string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.findAlias(scrapedName, trustNo) != nil {
+		return nil
+	}
+
+	return m.addAlias(scrapedName, trustNo, source, AliasStatusProposed, nil)
+}
+
+func (m *MemoryStore) SetFundNameAliasStatusContext(ctx context.Context, scrapedName string, trustNo int, status string, confirmedBy string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+
+	if status == AliasStatusConfirmed {
+		for i := range m.aliases {
+			alias := &m.aliases[i]
+			if alias.ScrapedName == scrapedName && alias.TrustNo != trustNo && alias.Status == AliasStatusConfirmed {
+				alias.Status = AliasStatusRejected
+				alias.ConfirmedBy = &confirmedBy
+				alias.UpdatedAt = now
+			}
+		}
+	}
+
+	if alias := m.findAlias(scrapedName, trustNo); alias != nil {
+		alias.Status = status
+		alias.ConfirmedBy = &confirmedBy
+		alias.UpdatedAt = now
+		return nil
+	}
+
+	return m.addAlias(scrapedName, trustNo, AliasSourceManual, status, &confirmedBy)
+}
+
+func (m *MemoryStore) findAlias(scrapedName string, trustNo int) *models.FundNameAlias {
+	for i := range m.aliases {
+		if m.aliases[i].ScrapedName == scrapedName && m.aliases[i].TrustNo == trustNo {
+			return &m.aliases[i]
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) addAlias(scrapedName string, trustNo int, source string, status string, confirmedBy *string) error {
+	if _, exists := m.funds[trustNo]; !exists {
+		return fmt.Errorf("alias %s references unknown fund %d", scrapedName, trustNo)
+	}
+
+	now := time.Now().UTC()
+	m.aliases = append(m.aliases, models.FundNameAlias{
+		ID:          len(m.aliases) + 1,
+		ScrapedName: scrapedName,
+		TrustNo:     trustNo,
+		Source:      source,
+		Status:      status,
+		ConfirmedBy: confirmedBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+
+	return nil
+}
+
+func (m *MemoryStore) SavePeerRankingsContext(ctx context.Context, date models.Date, rankings []*models.PeerRanking) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.peerRankings = slices.DeleteFunc(m.peerRankings, func(r models.PeerRanking) bool {
+		return r.SnapshotDate == date
+	})
+
+	for _, ranking := range rankings {
+		m.nextRankingID++
+		ranking.ID = m.nextRankingID
+		ranking.SnapshotDate = date
+		m.peerRankings = append(m.peerRankings, *ranking)
+	}
+
+	return nil
+}
+
+func (m *MemoryStore) GetLatestPeerRankingsContext(ctx context.Context, fundClassID int) ([]*models.PeerRanking, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var latest *models.Date
+	for _, r := range m.peerRankings {
+		if r.FundClassID == fundClassID && (latest == nil || r.SnapshotDate.After(*latest)) {
+			latest = &r.SnapshotDate
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+
+	var rankings []*models.PeerRanking
+	for _, r := range m.peerRankings {
+		if r.FundClassID == fundClassID && r.SnapshotDate == *latest {
+			rankings = append(rankings, &r)
+		}
+	}
+
+	sort.Slice(rankings, func(i, j int) bool {
+		return rankings[i].Metric < rankings[j].Metric
+	})
+
+	return rankings, nil
+}
+
+func (m *MemoryStore) ListPeerRankingsContext(ctx context.Context, fundClassID int, metric string, from *models.Date, to *models.Date, page Page) ([]*models.PeerRanking, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var rankings []*models.PeerRanking
+	for _, r := range m.peerRankings {
+		if r.FundClassID != fundClassID || r.Metric != metric {
+			continue
+		}
+		if (from != nil && r.SnapshotDate.Before(*from)) || (to != nil && r.SnapshotDate.After(*to)) {
+			continue
+		}
+		rankings = append(rankings, &r)
+	}
+
+	sort.Slice(rankings, func(i, j int) bool {
+		return rankings[i].SnapshotDate.Before(rankings[j].SnapshotDate)
+	})
+
+	start, end := page.window(len(rankings))
+	return rankings[start:end], len(rankings), nil
+}
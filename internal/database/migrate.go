@@ -0,0 +1,196 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*/*.sql
+var migrationFiles embed.FS
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// LoadMigrations returns the ordered migrations for a driver. Every driver
+// carries the same versions, written in its own SQL dialect.
+func LoadMigrations(driver string) ([]*Migration, error) {
+	dir := "migrations/" + driver
+
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		versionStr, name, ok := strings.Cut(strings.TrimSuffix(fileName, "."+direction+".sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name: %s", fileName)
+		}
+
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", fileName, err)
+		}
+
+		contents, err := fs.ReadFile(migrationFiles, dir+"/"+fileName)
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration %s: %w", fileName, err)
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+
+		if direction == "up" {
+			migration.Up = string(contents)
+		} else {
+			migration.Down = string(contents)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %05d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func (db *DB) ensureSchemaVersionTable() error {
+	query := `
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version INT PRIMARY KEY,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("error creating schema_version table: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) SchemaVersion() (int, error) {
+	if err := db.ensureSchemaVersionTable(); err != nil {
+		return 0, err
+	}
+
+	var version int
+	err := db.conn.Get(&version, "SELECT COALESCE(MAX(version), 0) FROM schema_version")
+	if err != nil {
+		return 0, fmt.Errorf("error reading schema version: %w", err)
+	}
+
+	return version, nil
+}
+
+// MigrateUp applies every migration newer than the current schema version,
+// each in its own transaction, and returns the number applied.
+func (db *DB) MigrateUp() (int, error) {
+	migrations, err := LoadMigrations(db.driver)
+	if err != nil {
+		return 0, err
+	}
+
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, migration := range migrations {
+		if migration.Version <= current {
+			continue
+		}
+
+		if err := db.applyMigration(migration.Up, "INSERT INTO schema_version (version) VALUES ($1)", migration.Version); err != nil {
+			return applied, fmt.Errorf("error applying migration %05d_%s: %w", migration.Version, migration.Name, err)
+		}
+		applied++
+	}
+
+	return applied, nil
+}
+
+// MigrateDown reverts up to steps of the most recently applied migrations and
+// returns the number reverted.
+func (db *DB) MigrateDown(steps int) (int, error) {
+	migrations, err := LoadMigrations(db.driver)
+	if err != nil {
+		return 0, err
+	}
+
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return 0, err
+	}
+
+	reverted := 0
+	for i := len(migrations) - 1; i >= 0 && reverted < steps; i-- {
+		migration := migrations[i]
+		if migration.Version > current {
+			continue
+		}
+
+		if migration.Down == "" {
+			return reverted, fmt.Errorf("migration %05d_%s has no down script", migration.Version, migration.Name)
+		}
+
+		if err := db.applyMigration(migration.Down, "DELETE FROM schema_version WHERE version = $1", migration.Version); err != nil {
+			return reverted, fmt.Errorf("error reverting migration %05d_%s: %w", migration.Version, migration.Name, err)
+		}
+		reverted++
+	}
+
+	return reverted, nil
+}
+
+func (db *DB) applyMigration(script string, versionQuery string, version int) error {
+	tx, err := db.conn.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(script); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(versionQuery, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
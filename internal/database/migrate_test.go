@@ -0,0 +1,86 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestMigrationsPairUp fails when a migration exists for one driver only,
+// under a different name, or without a down script.
+func TestMigrationsPairUp(t *testing.T) {
+	postgres, err := LoadMigrations("postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlite, err := LoadMigrations("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(postgres) != len(sqlite) {
+		t.Fatalf("%d postgres migrations, %d sqlite migrations", len(postgres), len(sqlite))
+	}
+
+	for i := range postgres {
+		p, s := postgres[i], sqlite[i]
+		if p.Version != i+1 || s.Version != i+1 {
+			t.Errorf("migration %d is numbered %d for postgres and %d for sqlite", i+1, p.Version, s.Version)
+		}
+		if p.Name != s.Name {
+			t.Errorf("migration %05d is %s for postgres and %s for sqlite", p.Version, p.Name, s.Name)
+		}
+		for driver, m := range map[string]*Migration{"postgres": p, "sqlite": s} {
+			if m.Down == "" {
+				t.Errorf("%s migration %05d_%s has no down script", driver, m.Version, m.Name)
+			}
+		}
+	}
+}
+
+func TestMigrateUpAndDown(t *testing.T) {
+	db, err := NewDB(&DbConfig{DSN: "sqlite://" + filepath.Join(t.TempDir(), "migrate.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	migrations, err := LoadMigrations("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := migrations[len(migrations)-1].Version
+
+	version := func() int {
+		t.Helper()
+		v, err := db.SchemaVersion()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	if applied, err := db.MigrateUp(); err != nil || applied != len(migrations) || version() != latest {
+		t.Fatalf("up: applied %d, %v, version %d, want %d at version %d", applied, err, version(), len(migrations), latest)
+	}
+
+	if applied, err := db.MigrateUp(); err != nil || applied != 0 {
+		t.Errorf("up again: applied %d, %v, want nothing", applied, err)
+	}
+
+	if reverted, err := db.MigrateDown(1); err != nil || reverted != 1 || version() != latest-1 {
+		t.Fatalf("down 1: reverted %d, %v, version %d, want 1 to version %d", reverted, err, version(), latest-1)
+	}
+
+	if applied, err := db.MigrateUp(); err != nil || applied != 1 || version() != latest {
+		t.Fatalf("up after down: applied %d, %v, version %d, want 1 to version %d", applied, err, version(), latest)
+	}
+
+	// Every down script runs, and the schema rebuilds from nothing.
+	if reverted, err := db.MigrateDown(len(migrations)); err != nil || reverted != len(migrations) || version() != 0 {
+		t.Fatalf("down all: reverted %d, %v, version %d, want %d to version 0", reverted, err, version(), len(migrations))
+	}
+
+	if applied, err := db.MigrateUp(); err != nil || applied != len(migrations) || version() != latest {
+		t.Fatalf("up from nothing: applied %d, %v, version %d, want %d", applied, err, version(), len(migrations))
+	}
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS fund_classes;
+
+DROP TYPE IF EXISTS target_market_type;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS scrape_run_checkpoints;
+
+ALTER TABLE scrape_runs DROP COLUMN resumed_from;
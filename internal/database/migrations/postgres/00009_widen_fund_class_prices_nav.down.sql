@@ -0,0 +1 @@
+ALTER TABLE fund_class_prices ALTER COLUMN nav TYPE DECIMAL(12,2);
@@ -0,0 +1,2 @@
+-- NAVs are published to four decimal places, DECIMAL(12,2) rounded them to cents.
+ALTER TABLE fund_class_prices ALTER COLUMN nav TYPE DECIMAL(18,6);
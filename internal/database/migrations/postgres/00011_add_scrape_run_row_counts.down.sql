@@ -0,0 +1,2 @@
+ALTER TABLE scrape_runs DROP COLUMN rows_dropped;
+ALTER TABLE scrape_runs DROP COLUMN rows_parsed;
@@ -0,0 +1,2 @@
+ALTER TABLE scrape_runs ADD COLUMN rows_parsed INT NOT NULL DEFAULT 0;
+ALTER TABLE scrape_runs ADD COLUMN rows_dropped INT NOT NULL DEFAULT 0;
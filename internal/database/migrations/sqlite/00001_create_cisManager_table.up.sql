@@ -0,0 +1,4 @@
+CREATE TABLE cisManagers (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name VARCHAR(255) NOT NULL
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS scrape_run_items;
+
+DROP TABLE IF EXISTS scrape_runs;
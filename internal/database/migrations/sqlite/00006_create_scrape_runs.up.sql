@@ -0,0 +1,27 @@
+CREATE TABLE scrape_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    mode VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP,
+    matched INT NOT NULL DEFAULT 0,
+    unmatched INT NOT NULL DEFAULT 0,
+    saved_managers INT NOT NULL DEFAULT 0,
+    saved_funds INT NOT NULL DEFAULT 0,
+    saved_prices INT NOT NULL DEFAULT 0,
+    saved_costs INT NOT NULL DEFAULT 0,
+    error TEXT
+);
+
+CREATE INDEX idx_scrape_runs_started_at ON scrape_runs(started_at);
+
+CREATE TABLE scrape_run_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    run_id INT NOT NULL REFERENCES scrape_runs(id) ON DELETE CASCADE,
+    item VARCHAR(500) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    message TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_scrape_run_items_run_id ON scrape_run_items(run_id);
@@ -0,0 +1,8 @@
+ALTER TABLE scrape_runs ADD COLUMN resumed_from INT;
+
+CREATE TABLE scrape_run_checkpoints (
+    run_id INT NOT NULL REFERENCES scrape_runs(id) ON DELETE CASCADE,
+    item_key VARCHAR(100) NOT NULL,
+    completed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (run_id, item_key)
+);
@@ -0,0 +1,14 @@
+CREATE TABLE fund_name_aliases (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    scraped_name VARCHAR(500) NOT NULL,
+    trust_no INT NOT NULL REFERENCES funds(trust_no) ON DELETE CASCADE,
+    source VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    confirmed_by VARCHAR(100),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(scraped_name, trust_no)
+);
+
+CREATE INDEX idx_fund_name_aliases_scraped_name ON fund_name_aliases(scraped_name);
+CREATE INDEX idx_fund_name_aliases_status ON fund_name_aliases(status);
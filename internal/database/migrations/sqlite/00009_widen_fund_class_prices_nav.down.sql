@@ -0,0 +1,3 @@
+-- SQLite does not enforce DECIMAL precision, so nav already keeps every
+-- published decimal place. Kept to line up with the postgres versions.
+SELECT 1;
@@ -0,0 +1 @@
+ALTER TABLE scrape_runs DROP COLUMN warnings;
@@ -0,0 +1 @@
+ALTER TABLE scrape_runs ADD COLUMN warnings INT NOT NULL DEFAULT 0;
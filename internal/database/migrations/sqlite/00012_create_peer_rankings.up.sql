@@ -0,0 +1,16 @@
+CREATE TABLE peer_rankings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    snapshot_date DATE NOT NULL,
+    fund_class_id INT NOT NULL REFERENCES fund_classes(id) ON DELETE CASCADE,
+    category VARCHAR(255) NOT NULL,
+    metric VARCHAR(20) NOT NULL,
+    value REAL NOT NULL,
+    rank INT NOT NULL,
+    peers INT NOT NULL,
+    quartile INT NOT NULL,
+    percentile INT NOT NULL,
+    UNIQUE(snapshot_date, fund_class_id, metric)
+);
+
+CREATE INDEX idx_peer_rankings_fund_class_id ON peer_rankings(fund_class_id, metric);
+CREATE INDEX idx_peer_rankings_category ON peer_rankings(category, metric, snapshot_date);
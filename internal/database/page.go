@@ -0,0 +1,35 @@
+package database
+
+// Page selects a window of a list query. The List methods return the rows in
+// the window together with the total number of rows the query matches. A zero
+// Limit means no limit.
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// window returns the [start, end) slice bounds of the page within n rows.
+func (p Page) window(n int) (int, int) {
+	start := min(max(p.Offset, 0), n)
+	end := n
+	if p.Limit > 0 {
+		end = min(start+p.Limit, n)
+	}
+	return start, end
+}
+
+// pageClause returns the LIMIT and OFFSET to append to a query written with ?
+// placeholders, and their arguments. A Page without a Limit returns every row
+// from Offset on.
+func (db *DB) pageClause(page Page) (string, []any) {
+	offset := max(page.Offset, 0)
+	switch {
+	case page.Limit > 0:
+		return " LIMIT ? OFFSET ?", []any{page.Limit, offset}
+	case db.driver == sqliteDriver:
+		// SQLite only accepts OFFSET after a LIMIT, and reads -1 as no limit.
+		return " LIMIT -1 OFFSET ?", []any{offset}
+	default:
+		return " OFFSET ?", []any{offset}
+	}
+}
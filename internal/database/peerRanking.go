@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+const peerRankingColumns = "id, snapshot_date, fund_class_id, category, metric, value, rank, peers, quartile, percentile"
+
+// SavePeerRankingsContext stores the snapshot of date, replacing any
+// rankings already saved for it so a day can be recomputed.
+func (db *DB) SavePeerRankingsContext(ctx context.Context, date models.Date, rankings []*models.PeerRanking) error {
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM peer_rankings WHERE snapshot_date = $1", date); err != nil {
+		return fmt.Errorf("error clearing peer rankings of %s: %w", date, err)
+	}
+
+	query := `
+		INSERT INTO peer_rankings (snapshot_date, fund_class_id, category, metric, value, rank, peers, quartile, percentile)
+		VALUES (:snapshot_date, :fund_class_id, :category, :metric, :value, :rank, :peers, :quartile, :percentile)
+	`
+
+	for _, ranking := range rankings {
+		ranking.SnapshotDate = date
+		if _, err := tx.NamedExecContext(ctx, query, ranking); err != nil {
+			return fmt.Errorf("error saving %s ranking of fund class %d: %w", ranking.Metric, ranking.FundClassID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetLatestPeerRankingsContext returns the rankings of a fund class in the
+// latest snapshot it appears in, ordered by metric, or none when it has
+// never been ranked.
+func (db *DB) GetLatestPeerRankingsContext(ctx context.Context, fundClassID int) ([]*models.PeerRanking, error) {
+	query := `SELECT ` + peerRankingColumns + ` FROM peer_rankings
+		WHERE fund_class_id = $1
+			AND snapshot_date = (SELECT MAX(latest.snapshot_date) FROM peer_rankings latest WHERE latest.fund_class_id = $1)
+		ORDER BY metric`
+
+	var rankings []*models.PeerRanking
+	if err := db.conn.SelectContext(ctx, &rankings, query, fundClassID); err != nil {
+		return nil, fmt.Errorf("error getting peer rankings of fund class %d: %w", fundClassID, err)
+	}
+
+	return rankings, nil
+}
+
+// ListPeerRankingsContext lists the rankings of a fund class on one metric
+// oldest first, limited to from and to when they are set, to show how its
+// place among its peers moved.
+func (db *DB) ListPeerRankingsContext(ctx context.Context, fundClassID int, metric string, from *models.Date, to *models.Date, page Page) ([]*models.PeerRanking, int, error) {
+	where := " WHERE fund_class_id = ? AND metric = ?"
+	args := []any{fundClassID, metric}
+	if from != nil {
+		where += " AND snapshot_date >= ?"
+		args = append(args, *from)
+	}
+	if to != nil {
+		where += " AND snapshot_date <= ?"
+		args = append(args, *to)
+	}
+
+	var total int
+	countQuery := db.conn.Rebind("SELECT COUNT(*) FROM peer_rankings" + where)
+	if err := db.conn.GetContext(ctx, &total, countQuery, args...); err != nil {
+		return nil, 0, fmt.Errorf("error counting peer rankings of fund class %d: %w", fundClassID, err)
+	}
+
+	clause, pageArgs := db.pageClause(page)
+	query := db.conn.Rebind("SELECT " + peerRankingColumns + " FROM peer_rankings" + where + " ORDER BY snapshot_date" + clause)
+
+	var rankings []*models.PeerRanking
+	if err := db.conn.SelectContext(ctx, &rankings, query, append(args, pageArgs...)...); err != nil {
+		return nil, 0, fmt.Errorf("error listing peer rankings of fund class %d: %w", fundClassID, err)
+	}
+
+	return rankings, total, nil
+}
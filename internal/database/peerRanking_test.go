@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+func TestPeerRankingSnapshots(t *testing.T) {
+	db, err := NewDB(&DbConfig{DSN: "sqlite://" + filepath.Join(t.TempDir(), "peers.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.MigrateUp(); err != nil {
+		t.Fatal(err)
+	}
+
+	stores := map[string]interface {
+		Store
+		ReadStore
+	}{"memory": NewMemoryStore(), "sqlite": db}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			fillScreenerStore(t, store)
+
+			ranking := func(fundClassID int, metric string, rank int) *models.PeerRanking {
+				return &models.PeerRanking{FundClassID: fundClassID, Category: "Equity", Metric: metric, Value: float64(rank) / 10, Rank: rank, Peers: 2, Quartile: 1 + (rank-1)*3, Percentile: 1 + (rank-1)*99}
+			}
+
+			snapshots := []struct {
+				date     string
+				rankings []*models.PeerRanking
+			}{
+				{"2024-10-15", []*models.PeerRanking{ranking(1, "return_1y", 2), ranking(3, "return_1y", 1)}},
+				{"2024-10-16", []*models.PeerRanking{ranking(1, "return_1y", 2), ranking(3, "return_1y", 1)}},
+				// Rerunning a day replaces its snapshot.
+				{"2024-10-16", []*models.PeerRanking{ranking(1, "return_1y", 1), ranking(3, "return_1y", 2), ranking(1, "tic", 2), ranking(3, "tic", 1)}},
+			}
+			for _, s := range snapshots {
+				if err := store.SavePeerRankingsContext(ctx, *date(s.date), s.rankings); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			latest, err := store.GetLatestPeerRankingsContext(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, r := range latest {
+				got = append(got, fmt.Sprintf("%s %s %d/%d q%d", r.SnapshotDate, r.Metric, r.Rank, r.Peers, r.Quartile))
+			}
+			if want := []string{"2024-10-16 return_1y 1/2 q1", "2024-10-16 tic 2/2 q4"}; !slices.Equal(got, want) {
+				t.Errorf("latest = %q, want %q", got, want)
+			}
+
+			history, total, err := store.ListPeerRankingsContext(ctx, 1, "return_1y", nil, nil, Page{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 2 || history[0].SnapshotDate.String() != "2024-10-15" || history[0].Rank != 2 || history[1].Rank != 1 || history[1].Value != 0.1 {
+				t.Errorf("history = %d %+v %+v, want rank 2 then 1", total, history[0], history[1])
+			}
+
+			from := date("2024-10-16")
+			if _, total, err := store.ListPeerRankingsContext(ctx, 1, "return_1y", from, nil, Page{}); err != nil || total != 1 {
+				t.Errorf("history from %s = %d, %v, want 1", from, total, err)
+			}
+
+			if never, err := store.GetLatestPeerRankingsContext(ctx, 2); err != nil || len(never) != 0 {
+				t.Errorf("unranked class = %v, %v, want none", never, err)
+			}
+		})
+	}
+}
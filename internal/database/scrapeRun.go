@@ -0,0 +1,195 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+const (
+	RunStatusRunning   = "running"
+	RunStatusSucceeded = "succeeded"
+	RunStatusFailed    = "failed"
+	RunStatusCancelled = "cancelled"
+)
+
+const (
+	ItemStatusOK        = "ok"
+	ItemStatusUnmatched = "unmatched"
+	ItemStatusError     = "error"
+	ItemStatusWarning   = "warning"
+	ItemStatusDropped   = "dropped"
+)
+
+func (db *DB) CreateScrapeRunContext(ctx context.Context, run *models.ScrapeRun) error {
+	query := `
+		INSERT INTO scrape_runs (mode, status, started_at, resumed_from)
+		VALUES (:mode, :status, :started_at, :resumed_from)
+		RETURNING id
+	`
+
+	rows, err := db.conn.NamedQueryContext(ctx, query, run)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&run.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) FinishScrapeRunContext(ctx context.Context, run *models.ScrapeRun) error {
+	query := `
+		UPDATE scrape_runs
+		SET status = :status,
+			finished_at = :finished_at,
+			matched = :matched,
+			unmatched = :unmatched,
+			saved_managers = :saved_managers,
+			saved_funds = :saved_funds,
+			saved_prices = :saved_prices,
+			saved_costs = :saved_costs,
+			warnings = :warnings,
+			rows_parsed = :rows_parsed,
+			rows_dropped = :rows_dropped,
+			error = :error
+		WHERE id = :id
+	`
+
+	_, err := db.conn.NamedExecContext(ctx, query, run)
+
+	return err
+}
+
+func (db *DB) SaveScrapeRunItemContext(ctx context.Context, item *models.ScrapeRunItem) error {
+	query := `
+		INSERT INTO scrape_run_items (run_id, item, status, message)
+		VALUES (:run_id, :item, :status, :message)
+	`
+
+	_, err := db.conn.NamedExecContext(ctx, query, item)
+
+	return err
+}
+
+func (db *DB) ListScrapeRunsContext(ctx context.Context, limit int) ([]*models.ScrapeRun, error) {
+	var runs []*models.ScrapeRun
+
+	query := `
+		SELECT id, mode, status, started_at, finished_at, matched, unmatched,
+			saved_managers, saved_funds, saved_prices, saved_costs, warnings, rows_parsed, rows_dropped, error, resumed_from
+		FROM scrape_runs
+		ORDER BY id DESC
+		LIMIT $1
+	`
+
+	err := db.conn.SelectContext(ctx, &runs, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing scrape runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// GetScrapeRunContext returns nil when there is no run with the given id.
+func (db *DB) GetScrapeRunContext(ctx context.Context, id int) (*models.ScrapeRun, error) {
+	var runs []*models.ScrapeRun
+
+	query := `
+		SELECT id, mode, status, started_at, finished_at, matched, unmatched,
+			saved_managers, saved_funds, saved_prices, saved_costs, warnings, rows_parsed, rows_dropped, error, resumed_from
+		FROM scrape_runs
+		WHERE id = $1
+	`
+
+	err := db.conn.SelectContext(ctx, &runs, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting scrape run %d: %w", id, err)
+	}
+
+	if len(runs) == 0 {
+		return nil, nil
+	}
+
+	return runs[0], nil
+}
+
+func (db *DB) GetScrapeRunItemsContext(ctx context.Context, runID int) ([]*models.ScrapeRunItem, error) {
+	var items []*models.ScrapeRunItem
+
+	query := `
+		SELECT id, run_id, item, status, message
+		FROM scrape_run_items
+		WHERE run_id = $1
+		ORDER BY id
+	`
+
+	err := db.conn.SelectContext(ctx, &items, query, runID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting items for scrape run %d: %w", runID, err)
+	}
+
+	return items, nil
+}
+
+// GetLatestScrapeRunContext returns the most recent run of mode, or nil when
+// there is none.
+func (db *DB) GetLatestScrapeRunContext(ctx context.Context, mode string) (*models.ScrapeRun, error) {
+	var runs []*models.ScrapeRun
+
+	query := `
+		SELECT id, mode, status, started_at, finished_at, matched, unmatched,
+			saved_managers, saved_funds, saved_prices, saved_costs, warnings, rows_parsed, rows_dropped, error, resumed_from
+		FROM scrape_runs
+		WHERE mode = $1
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	err := db.conn.SelectContext(ctx, &runs, query, mode)
+	if err != nil {
+		return nil, fmt.Errorf("error getting latest %s scrape run: %w", mode, err)
+	}
+
+	if len(runs) == 0 {
+		return nil, nil
+	}
+
+	return runs[0], nil
+}
+
+func (db *DB) SaveScrapeRunCheckpointContext(ctx context.Context, runID int, itemKey string) error {
+	query := `
+		INSERT INTO scrape_run_checkpoints (run_id, item_key)
+		VALUES ($1, $2)
+		ON CONFLICT (run_id, item_key) DO NOTHING
+	`
+
+	_, err := db.conn.ExecContext(ctx, query, runID, itemKey)
+
+	return err
+}
+
+func (db *DB) GetScrapeRunCheckpointsContext(ctx context.Context, runID int) ([]string, error) {
+	var itemKeys []string
+
+	query := `
+		SELECT item_key
+		FROM scrape_run_checkpoints
+		WHERE run_id = $1
+		ORDER BY completed_at, item_key
+	`
+
+	err := db.conn.SelectContext(ctx, &itemKeys, query, runID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting checkpoints for scrape run %d: %w", runID, err)
+	}
+
+	return itemKeys, nil
+}
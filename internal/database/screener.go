@@ -0,0 +1,409 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+// Screen selects and orders fund classes. Filter is written in the screener
+// filter language, Sort names a screener field and sorts descending when it
+// starts with -. Classes without a value for the sort field come last.
+type Screen struct {
+	Filter string
+	Sort   string
+}
+
+type fieldKind int
+
+const (
+	kindText fieldKind = iota
+	kindNumber
+	kindInteger
+	kindBoolean
+)
+
+func (k fieldKind) allows(op string) bool {
+	switch k {
+	case kindText:
+		return slices.Contains([]string{"=", "!=", "~", "!~"}, op)
+	case kindNumber, kindInteger:
+		return slices.Contains([]string{"=", "!=", "<", "<=", ">", ">="}, op)
+	default:
+		return op == "=" || op == "!="
+	}
+}
+
+func (k fieldKind) parse(t token) (any, error) {
+	switch k {
+	case kindText:
+		if t.kind != tokenString && t.kind != tokenWord {
+			return nil, fmt.Errorf("expects text but found %q", t.text)
+		}
+		return strings.ToLower(t.text), nil
+	case kindNumber, kindInteger:
+		if t.kind != tokenNumber {
+			return nil, fmt.Errorf("expects a number but found %q", t.text)
+		}
+		value, err := models.ParseDecimal(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("expects a number but found %q", t.text)
+		}
+		if k == kindInteger && strings.Contains(t.text, ".") {
+			return nil, fmt.Errorf("expects a whole number but found %q", t.text)
+		}
+		return value, nil
+	default:
+		if t.kind == tokenWord && (strings.EqualFold(t.text, "true") || strings.EqualFold(t.text, "false")) {
+			return strings.EqualFold(t.text, "true"), nil
+		}
+		return nil, fmt.Errorf("expects true or false but found %q", t.text)
+	}
+}
+
+// screenField is a field filters and sorts may name. column is the SQL it
+// stands for, so user input never reaches the query text.
+type screenField struct {
+	name   string
+	kind   fieldKind
+	column string
+	value  func(s *models.FundClassSummary) any
+}
+
+var screenFields = map[string]*screenField{}
+
+func init() {
+	decimal := func(d *models.Decimal) any {
+		if d == nil {
+			return nil
+		}
+		return *d
+	}
+
+	for _, field := range []*screenField{
+		{name: "fund", kind: kindText, column: "f.name",
+			value: func(s *models.FundClassSummary) any { return s.FundName }},
+		{name: "trust_no", kind: kindInteger, column: "f.trust_no",
+			value: func(s *models.FundClassSummary) any { return models.NewDecimal(int64(s.TrustNo), 0) }},
+		{name: "class", kind: kindText, column: "fc.class_name",
+			value: func(s *models.FundClassSummary) any { return s.ClassName }},
+		{name: "manager", kind: kindText, column: "m.name",
+			value: func(s *models.FundClassSummary) any { return s.ManagerName }},
+		{name: "manager_id", kind: kindInteger, column: "m.id",
+			value: func(s *models.FundClassSummary) any { return models.NewDecimal(int64(s.ManagerID), 0) }},
+		{name: "category", kind: kindText, column: "COALESCE(fc.category, '')",
+			value: func(s *models.FundClassSummary) any { return s.Category }},
+		{name: "target_market", kind: kindText, column: "COALESCE(CAST(fc.target_market AS TEXT), '')",
+			value: func(s *models.FundClassSummary) any { return s.TargetMarket }},
+		{name: "add_fee", kind: kindBoolean, column: "COALESCE(fc.add_fee, false)",
+			value: func(s *models.FundClassSummary) any { return s.AddFee }},
+		{name: "max_init_fee", kind: kindNumber, column: "fc.max_init_fee",
+			value: func(s *models.FundClassSummary) any { return decimal(s.MaxInitFee) }},
+		{name: "ter_perf_comp", kind: kindNumber, column: "lc.ter_perf_comp",
+			value: func(s *models.FundClassSummary) any { return decimal(s.TERPerfComp) }},
+		{name: "ter", kind: kindNumber, column: "lc.ter",
+			value: func(s *models.FundClassSummary) any { return decimal(s.TER) }},
+		{name: "tc", kind: kindNumber, column: "lc.tc",
+			value: func(s *models.FundClassSummary) any { return decimal(s.TC) }},
+		{name: "tic", kind: kindNumber, column: "lc.tic",
+			value: func(s *models.FundClassSummary) any { return decimal(s.TIC) }},
+	} {
+		screenFields[field.name] = field
+	}
+}
+
+// ScreenFields returns the names of the fields screener filters and sorts may
+// use, in alphabetical order.
+func ScreenFields() []string {
+	names := make([]string, 0, len(screenFields))
+	for name := range screenFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// comparison is a field, operator and value leaf of a filter.
+type comparison struct {
+	field *screenField
+	op    string
+	value any
+}
+
+func (c comparison) sql(args *[]any) string {
+	switch c.op {
+	case "~", "!~":
+		*args = append(*args, "%"+escapeLike(c.value.(string))+"%")
+		not := ""
+		if c.op == "!~" {
+			not = "NOT "
+		}
+		return c.field.compared() + " " + not + "LIKE ? ESCAPE '\\'"
+	case "!=":
+		*args = append(*args, c.value)
+		return c.field.compared() + " <> ?"
+	default:
+		*args = append(*args, c.value)
+		return c.field.compared() + " " + c.op + " ?"
+	}
+}
+
+// compared is the SQL the field is compared and sorted by, which ignores
+// case for text.
+func (f *screenField) compared() string {
+	if f.kind == kindText {
+		return "LOWER(" + f.column + ")"
+	}
+	return f.column
+}
+
+func (c comparison) match(summary *models.FundClassSummary) truth {
+	value := c.field.value(summary)
+	if value == nil {
+		return truthUnknown
+	}
+
+	var result bool
+	switch c.field.kind {
+	case kindText:
+		text := strings.ToLower(value.(string))
+		switch c.op {
+		case "=":
+			result = text == c.value
+		case "!=":
+			result = text != c.value
+		case "~":
+			result = strings.Contains(text, c.value.(string))
+		case "!~":
+			result = !strings.Contains(text, c.value.(string))
+		}
+	case kindBoolean:
+		result = (value == c.value) == (c.op == "=")
+	default:
+		cmp := value.(models.Decimal).Cmp(c.value.(models.Decimal))
+		switch c.op {
+		case "=":
+			result = cmp == 0
+		case "!=":
+			result = cmp != 0
+		case "<":
+			result = cmp < 0
+		case "<=":
+			result = cmp <= 0
+		case ">":
+			result = cmp > 0
+		case ">=":
+			result = cmp >= 0
+		}
+	}
+
+	if result {
+		return truthTrue
+	}
+	return truthFalse
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// compiledScreen is a Screen checked against the screener fields.
+type compiledScreen struct {
+	filter     filterNode
+	sortField  *screenField
+	descending bool
+}
+
+func compileScreen(screen Screen) (*compiledScreen, error) {
+	filter, err := parseFilter(screen.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled := &compiledScreen{filter: filter}
+
+	sortName := strings.ToLower(strings.TrimSpace(screen.Sort))
+	if sortName != "" {
+		if strings.HasPrefix(sortName, "-") {
+			compiled.descending = true
+			sortName = sortName[1:]
+		}
+
+		field, ok := screenFields[sortName]
+		if !ok {
+			return nil, &FilterError{Filter: screen.Sort, Msg: fmt.Sprintf("unknown sort field %q, expected one of %s", sortName, strings.Join(ScreenFields(), ", "))}
+		}
+		compiled.sortField = field
+	}
+
+	return compiled, nil
+}
+
+const screenSelect = `
+	SELECT fc.id AS fund_class_id, f.trust_no, f.name AS fund_name, fc.class_name,
+		m.id AS manager_id, m.name AS manager_name,
+		COALESCE(fc.category, '') AS category, COALESCE(CAST(fc.target_market AS TEXT), '') AS target_market,
+		COALESCE(fc.add_fee, false) AS add_fee, fc.max_init_fee,
+		lc.tic_date AS costs_date, lc.ter_perf_comp, lc.ter, lc.tc, lc.tic
+`
+
+const screenFrom = `
+	FROM fund_classes fc
+	JOIN funds f ON f.trust_no = fc.fund_id
+	JOIN cisManagers m ON m.id = f.manager_id
+	LEFT JOIN fund_class_costs lc ON lc.fund_class_id = fc.id
+		AND lc.tic_date = (SELECT MAX(latest.tic_date) FROM fund_class_costs latest WHERE latest.fund_class_id = fc.id)
+`
+
+// ScreenFundClassesContext returns the fund classes that pass the screen's
+// filter, with their latest costs. A filter that does not parse returns a
+// *FilterError.
+func (db *DB) ScreenFundClassesContext(ctx context.Context, screen Screen, page Page) ([]*models.FundClassSummary, int, error) {
+	compiled, err := compileScreen(screen)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var args []any
+	where := ""
+	if compiled.filter != nil {
+		where = " WHERE " + compiled.filter.sql(&args)
+	}
+
+	var total int
+	countQuery := db.conn.Rebind("SELECT COUNT(*)" + screenFrom + where)
+	if err := db.conn.GetContext(ctx, &total, countQuery, args...); err != nil {
+		return nil, 0, fmt.Errorf("error counting screened fund classes: %w", err)
+	}
+
+	order := " ORDER BY fc.id"
+	if field := compiled.sortField; field != nil {
+		direction := ""
+		if compiled.descending {
+			direction = " DESC"
+		}
+		order = fmt.Sprintf(" ORDER BY CASE WHEN %s IS NULL THEN 1 ELSE 0 END, %s%s, fc.id", field.column, field.compared(), direction)
+	}
+
+	clause, pageArgs := db.pageClause(page)
+	query := db.conn.Rebind(screenSelect + screenFrom + where + order + clause)
+
+	var summaries []*models.FundClassSummary
+	if err := db.conn.SelectContext(ctx, &summaries, query, append(args, pageArgs...)...); err != nil {
+		return nil, 0, fmt.Errorf("error screening fund classes: %w", err)
+	}
+
+	return summaries, total, nil
+}
+
+// ScreenFundClassesContext evaluates the screen in memory with the same
+// results as DB.
+func (m *MemoryStore) ScreenFundClassesContext(ctx context.Context, screen Screen, page Page) ([]*models.FundClassSummary, int, error) {
+	compiled, err := compileScreen(screen)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	m.mu.Lock()
+	summaries := m.summaries()
+	m.mu.Unlock()
+
+	if compiled.filter != nil {
+		summaries = slices.DeleteFunc(summaries, func(s *models.FundClassSummary) bool {
+			return compiled.filter.match(s) != truthTrue
+		})
+	}
+
+	if field := compiled.sortField; field != nil {
+		sort.SliceStable(summaries, func(i, j int) bool {
+			a, b := field.value(summaries[i]), field.value(summaries[j])
+			if a == nil || b == nil {
+				return b == nil && a != nil
+			}
+
+			cmp := compareValues(a, b)
+			if compiled.descending {
+				cmp = -cmp
+			}
+			return cmp < 0
+		})
+	}
+
+	start, end := page.window(len(summaries))
+	return summaries[start:end], len(summaries), nil
+}
+
+// summaries joins every fund class with its fund, manager and latest costs,
+// ordered by fund class id. The caller holds m.mu.
+func (m *MemoryStore) summaries() []*models.FundClassSummary {
+	latest := make(map[int]models.FundClassCost)
+	for key, cost := range m.costs {
+		if cost.TICDate == nil {
+			continue
+		}
+		if current, ok := latest[key.fundClassID]; !ok || cost.TICDate.After(*current.TICDate) {
+			latest[key.fundClassID] = cost
+		}
+	}
+
+	summaries := make([]*models.FundClassSummary, 0, len(m.fundClasses))
+	for id, fundClass := range m.fundClasses {
+		fund := m.funds[fundClass.FundID]
+		summary := &models.FundClassSummary{
+			FundClassID:  id,
+			TrustNo:      fund.TrustNo,
+			FundName:     fund.Name,
+			ClassName:    fundClass.ClassName,
+			ManagerID:    fund.ManagerID,
+			ManagerName:  m.managers[fund.ManagerID].Name,
+			Category:     fundClass.Category,
+			TargetMarket: fundClass.TargetMarket,
+			AddFee:       fundClass.AddFee,
+			MaxInitFee:   fundClass.MaxInitFee,
+		}
+
+		if cost, ok := latest[id]; ok {
+			summary.CostsDate = cost.TICDate
+			summary.TERPerfComp = cost.TERPerfComp
+			summary.TER = cost.TER
+			summary.TC = cost.TC
+			summary.TIC = cost.TIC
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].FundClassID < summaries[j].FundClassID
+	})
+
+	return summaries
+}
+
+func compareValues(a, b any) int {
+	switch a := a.(type) {
+	case models.Decimal:
+		return a.Cmp(b.(models.Decimal))
+	case string:
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b.(string)))
+	case bool:
+		switch {
+		case a == b.(bool):
+			return 0
+		case !a:
+			return -1
+		default:
+			return 1
+		}
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,330 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+// A screener filter is a boolean expression over the screener fields:
+//
+//	class = "Class A" and category = "South African - Equity - General"
+//	    and tic < 1.5% and add_fee = false
+//
+// Comparisons are field, operator, value. Text fields take = != and the
+// case-insensitive substring operators ~ and !~, and compare without regard
+// to case. Number fields take = != < <= > >= and an optional % after the
+// value, since costs and fees are stored as percentages. Boolean fields take
+// = and != with true or false. Comparisons combine with and, or, not and
+// parentheses. Text values are quoted with ' or ", single words may be left
+// bare. A number field without a value never matches, as in SQL.
+
+// FilterError reports a filter that does not parse. Pos is the byte offset
+// of the problem in the filter.
+type FilterError struct {
+	Filter string
+	Pos    int
+	Msg    string
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("invalid filter at %d: %s", e.Pos, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenNumber
+	tokenString
+	tokenOperator
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func lexFilter(filter string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(filter); {
+		c := rune(filter[i])
+		start := i
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+			continue
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")", pos: i})
+			i++
+		case c == '"' || c == '\'':
+			var value strings.Builder
+			i++
+			for i < len(filter) && rune(filter[i]) != c {
+				if filter[i] == '\\' && i+1 < len(filter) {
+					i++
+				}
+				value.WriteByte(filter[i])
+				i++
+			}
+			if i == len(filter) {
+				return nil, &FilterError{Filter: filter, Pos: start, Msg: "unterminated string"}
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, text: value.String(), pos: start})
+		case strings.ContainsRune("=!<>~", c):
+			for i < len(filter) && strings.ContainsRune("=!<>~", rune(filter[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: filter[start:i], pos: start})
+		case c == '-' || c == '.' || unicode.IsDigit(c):
+			i++
+			for i < len(filter) && (filter[i] == '.' || unicode.IsDigit(rune(filter[i]))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: filter[start:i], pos: start})
+			if i < len(filter) && filter[i] == '%' {
+				i++
+			}
+		case c == '_' || unicode.IsLetter(c):
+			for i < len(filter) && (filter[i] == '_' || unicode.IsLetter(rune(filter[i])) || unicode.IsDigit(rune(filter[i]))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: filter[start:i], pos: start})
+		default:
+			return nil, &FilterError{Filter: filter, Pos: i, Msg: fmt.Sprintf("unexpected %q", c)}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(filter)}), nil
+}
+
+// filterNode is a parsed filter. sql writes it as a condition with ?
+// placeholders, match evaluates it against a summary with SQL's three valued
+// logic so MemoryStore screens exactly like DB.
+type filterNode interface {
+	sql(args *[]any) string
+	match(summary *models.FundClassSummary) truth
+}
+
+// truth is a SQL boolean: true, false or unknown when a NULL was compared.
+type truth int
+
+const (
+	truthUnknown truth = iota
+	truthFalse
+	truthTrue
+)
+
+type andNode struct{ left, right filterNode }
+
+func (n andNode) sql(args *[]any) string {
+	return "(" + n.left.sql(args) + " AND " + n.right.sql(args) + ")"
+}
+
+func (n andNode) match(summary *models.FundClassSummary) truth {
+	left, right := n.left.match(summary), n.right.match(summary)
+	switch {
+	case left == truthFalse || right == truthFalse:
+		return truthFalse
+	case left == truthTrue && right == truthTrue:
+		return truthTrue
+	default:
+		return truthUnknown
+	}
+}
+
+type orNode struct{ left, right filterNode }
+
+func (n orNode) sql(args *[]any) string {
+	return "(" + n.left.sql(args) + " OR " + n.right.sql(args) + ")"
+}
+
+func (n orNode) match(summary *models.FundClassSummary) truth {
+	left, right := n.left.match(summary), n.right.match(summary)
+	switch {
+	case left == truthTrue || right == truthTrue:
+		return truthTrue
+	case left == truthFalse && right == truthFalse:
+		return truthFalse
+	default:
+		return truthUnknown
+	}
+}
+
+type notNode struct{ node filterNode }
+
+func (n notNode) sql(args *[]any) string {
+	return "NOT " + n.node.sql(args)
+}
+
+func (n notNode) match(summary *models.FundClassSummary) truth {
+	switch n.node.match(summary) {
+	case truthTrue:
+		return truthFalse
+	case truthFalse:
+		return truthTrue
+	default:
+		return truthUnknown
+	}
+}
+
+type filterParser struct {
+	filter string
+	tokens []token
+	pos    int
+}
+
+// parseFilter parses filter, returning nil for an empty filter.
+func parseFilter(filter string) (filterNode, error) {
+	tokens, err := lexFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{filter: filter, tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, nil
+	}
+
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, p.errorf(next, "unexpected %q", next.text)
+	}
+
+	return node, nil
+}
+
+func (p *filterParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *filterParser) keyword(word string) bool {
+	if t := p.peek(); t.kind == tokenWord && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) errorf(t token, format string, args ...any) error {
+	return &FilterError{Filter: p.filter, Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword("and") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	if p.keyword("not") {
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node: node}, nil
+	}
+
+	if p.peek().kind == tokenOpen {
+		p.next()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokenClose {
+			return nil, p.errorf(t, "expected ) but found %q", t.text)
+		}
+		return node, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (filterNode, error) {
+	name := p.next()
+	if name.kind != tokenWord {
+		return nil, p.errorf(name, "expected a field but found %q", name.text)
+	}
+
+	field, ok := screenFields[strings.ToLower(name.text)]
+	if !ok {
+		return nil, p.errorf(name, "unknown field %q, expected one of %s", name.text, strings.Join(ScreenFields(), ", "))
+	}
+
+	op := p.next()
+	if op.kind != tokenOperator {
+		return nil, p.errorf(op, "expected an operator after %s but found %q", field.name, op.text)
+	}
+
+	operator := op.text
+	switch operator {
+	case "==":
+		operator = "="
+	case "<>":
+		operator = "!="
+	}
+
+	if !field.kind.allows(operator) {
+		return nil, p.errorf(op, "%s does not support %s", field.name, op.text)
+	}
+
+	valueToken := p.next()
+	value, err := field.kind.parse(valueToken)
+	if err != nil {
+		return nil, p.errorf(valueToken, "%s %s", field.name, err)
+	}
+
+	return comparison{field: field, op: operator, value: value}, nil
+}
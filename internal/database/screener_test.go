@@ -0,0 +1,326 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+func decimal(s string) *models.Decimal {
+	d, err := models.ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return &d
+}
+
+func date(s string) *models.Date {
+	d, err := models.ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return &d
+}
+
+// fillScreenerStore saves two managers, four funds and six classes. The
+// Coronation Top 20 B class has no costs, so its TIC is NULL.
+func fillScreenerStore(t *testing.T, store Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	managers := []*models.CISManager{{ID: 303, Name: "Allan Gray"}, {ID: 37, Name: "Coronation"}}
+	if err := store.SaveCISManagersContext(ctx, managers); err != nil {
+		t.Fatal(err)
+	}
+
+	funds := []*models.Fund{
+		{TrustNo: 1234, Name: "Allan Gray Balanced Fund", ManagerID: 303},
+		{TrustNo: 1235, Name: "Allan Gray Equity Fund", ManagerID: 303},
+		{TrustNo: 2001, Name: "Coronation Top 20 Fund", ManagerID: 37},
+		{TrustNo: 2002, Name: "Coronation 100% Income Fund", ManagerID: 37},
+	}
+	if err := store.SaveFundsContext(ctx, funds); err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		equity   = "South African - Equity - General"
+		balanced = "South African - Multi Asset - High Equity"
+		income   = "South African - Multi Asset - Income"
+	)
+
+	for _, c := range []struct {
+		class models.FundClass
+		costs []models.FundClassCost
+	}{
+		{
+			class: models.FundClass{FundID: 1234, ClassName: "Class A", Category: balanced, TargetMarket: "Retail", MaxInitFee: decimal("0.00")},
+			costs: []models.FundClassCost{
+				{TICDate: date("2024-06-30"), TER: decimal("1.20"), TC: decimal("0.10"), TIC: decimal("1.30")},
+				{TICDate: date("2024-09-30"), TER: decimal("1.10"), TC: decimal("0.09"), TIC: decimal("1.19")},
+			},
+		},
+		{
+			class: models.FundClass{FundID: 1234, ClassName: "Class C", Category: balanced, TargetMarket: "Institutional", AddFee: true},
+			costs: []models.FundClassCost{{TICDate: date("2024-09-30"), TER: decimal("0.80"), TC: decimal("0.09"), TIC: decimal("0.89")}},
+		},
+		{
+			class: models.FundClass{FundID: 1235, ClassName: "Class A", Category: equity, TargetMarket: "Retail", MaxInitFee: decimal("3.00")},
+			costs: []models.FundClassCost{{TICDate: date("2024-09-30"), TER: decimal("1.40"), TC: decimal("0.20"), TIC: decimal("1.60")}},
+		},
+		{
+			class: models.FundClass{FundID: 2001, ClassName: "Class A", Category: equity, TargetMarket: "Retail"},
+			costs: []models.FundClassCost{{TICDate: date("2024-09-30"), TER: decimal("1.25"), TC: decimal("0.15"), TIC: decimal("1.40")}},
+		},
+		{
+			class: models.FundClass{FundID: 2001, ClassName: "Class B", Category: equity, TargetMarket: "Retail", AddFee: true},
+		},
+		{
+			class: models.FundClass{FundID: 2002, ClassName: "Class P", Category: income, TargetMarket: "Retail"},
+			costs: []models.FundClassCost{{TICDate: date("2024-09-30"), TER: decimal("0.60"), TC: decimal("0.05"), TIC: decimal("0.65")}},
+		},
+	} {
+		class := c.class
+		if err := store.SaveFundClassContext(ctx, &class); err != nil {
+			t.Fatal(err)
+		}
+		for _, cost := range c.costs {
+			cost.FundClassID = class.ID
+			if err := store.SaveFundClassCostsContext(ctx, &cost); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+// screenerStores returns a MemoryStore and a migrated SQLite DB holding the
+// same fund classes, so each screen can be checked against both.
+func screenerStores(t *testing.T) map[string]ReadStore {
+	t.Helper()
+
+	db, err := NewDB(&DbConfig{DSN: "sqlite://" + filepath.Join(t.TempDir(), "screener.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.MigrateUp(); err != nil {
+		t.Fatal(err)
+	}
+
+	memory := NewMemoryStore()
+	fillScreenerStore(t, memory)
+	fillScreenerStore(t, db)
+
+	return map[string]ReadStore{"memory": memory, "sqlite": db}
+}
+
+func classNames(summaries []*models.FundClassSummary) []string {
+	names := make([]string, 0, len(summaries))
+	for _, s := range summaries {
+		names = append(names, fmt.Sprintf("%d %s", s.TrustNo, s.ClassName))
+	}
+	return names
+}
+
+func TestScreenFundClasses(t *testing.T) {
+	tests := []struct {
+		name   string
+		screen Screen
+		want   []string
+	}{
+		{
+			name:   "no filter lists every class by id",
+			screen: Screen{},
+			want:   []string{"1234 Class A", "1234 Class C", "1235 Class A", "2001 Class A", "2001 Class B", "2002 Class P"},
+		},
+		{
+			name:   "the motivating question",
+			screen: Screen{Filter: `class = "class a" and category = "South African - Equity - General" and tic < 1.5% and add_fee = false`},
+			want:   []string{"2001 Class A"},
+		},
+		{
+			name:   "latest costs only",
+			screen: Screen{Filter: "tic >= 1.19 and tic < 1.3"},
+			want:   []string{"1234 Class A"},
+		},
+		{
+			name:   "substring ignores case",
+			screen: Screen{Filter: "category ~ 'MULTI ASSET' and not target_market = institutional"},
+			want:   []string{"1234 Class A", "2002 Class P"},
+		},
+		{
+			name:   "like wildcards are literal",
+			screen: Screen{Filter: "fund ~ '100%' or fund ~ '_'"},
+			want:   []string{"2002 Class P"},
+		},
+		{
+			name:   "missing costs never compare",
+			screen: Screen{Filter: "manager = coronation and (tic > 1 or tic <= 1)"},
+			want:   []string{"2001 Class A", "2002 Class P"},
+		},
+		{
+			name:   "not of an unknown stays unknown",
+			screen: Screen{Filter: "trust_no = 2001 and not tic > 5"},
+			want:   []string{"2001 Class A"},
+		},
+		{
+			name:   "missing max init fee",
+			screen: Screen{Filter: "max_init_fee != 3 or manager_id = 37"},
+			want:   []string{"1234 Class A", "2001 Class A", "2001 Class B", "2002 Class P"},
+		},
+		{
+			name:   "sort ascending puts missing values last",
+			screen: Screen{Filter: "manager_id == 37 or add_fee = true", Sort: "tic"},
+			want:   []string{"2002 Class P", "1234 Class C", "2001 Class A", "2001 Class B"},
+		},
+		{
+			name:   "sort descending puts missing values last",
+			screen: Screen{Filter: "manager_id <> 303", Sort: "-ter"},
+			want:   []string{"2001 Class A", "2002 Class P", "2001 Class B"},
+		},
+		{
+			name:   "sort text",
+			screen: Screen{Filter: "class = 'Class A'", Sort: "-fund"},
+			want:   []string{"2001 Class A", "1235 Class A", "1234 Class A"},
+		},
+	}
+
+	for name, store := range screenerStores(t) {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				summaries, total, err := store.ScreenFundClassesContext(context.Background(), tt.screen, Page{})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got := classNames(summaries); !slices.Equal(got, tt.want) {
+					t.Errorf("got %q, want %q", got, tt.want)
+				}
+				if total != len(tt.want) {
+					t.Errorf("total = %d, want %d", total, len(tt.want))
+				}
+			})
+		}
+	}
+}
+
+func TestScreenFundClassesReturnsLatestCostsAndPages(t *testing.T) {
+	for name, store := range screenerStores(t) {
+		t.Run(name, func(t *testing.T) {
+			summaries, total, err := store.ScreenFundClassesContext(context.Background(), Screen{Filter: "manager = 'allan gray'", Sort: "-tic"}, Page{Limit: 1, Offset: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if total != 3 || len(summaries) != 1 {
+				t.Fatalf("got %d of %d, want 1 of 3", len(summaries), total)
+			}
+
+			s := summaries[0]
+			if s.FundName != "Allan Gray Balanced Fund" || s.ClassName != "Class A" || s.ManagerName != "Allan Gray" || s.ManagerID != 303 {
+				t.Errorf("summary = %+v, want Allan Gray Balanced Fund Class A", s)
+			}
+			if s.CostsDate == nil || s.CostsDate.String() != "2024-09-30" || s.TIC == nil || !s.TIC.Equal(*decimal("1.19")) {
+				t.Errorf("costs = %v %v, want the 2024-09-30 TIC of 1.19", s.CostsDate, s.TIC)
+			}
+			if s.MaxInitFee == nil || !s.MaxInitFee.IsZero() {
+				t.Errorf("max init fee = %v, want 0", s.MaxInitFee)
+			}
+		})
+	}
+}
+
+func TestGetFundClassCostsAsOf(t *testing.T) {
+	for name, store := range screenerStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			classes, err := store.GetFundClassesByFundContext(ctx, 1234)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var classA int
+			for _, class := range classes {
+				if class.ClassName == "Class A" {
+					classA = class.ID
+				}
+			}
+
+			// Class A has costs dated 2024-06-30 and 2024-09-30.
+			tests := map[string]string{"2024-06-29": "", "2024-06-30": "1.30", "2024-09-29": "1.30", "2024-09-30": "1.19", "2024-12-31": "1.19"}
+			for asOf, want := range tests {
+				cost, err := store.GetFundClassCostsAsOfContext(ctx, classA, *date(asOf))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if want == "" {
+					if cost != nil {
+						t.Errorf("TIC as of %s = %s, want none", asOf, cost.TIC)
+					}
+					continue
+				}
+				if cost == nil || !cost.TIC.Equal(*decimal(want)) {
+					t.Errorf("costs as of %s = %+v, want a TIC of %s", asOf, cost, want)
+				}
+			}
+		})
+	}
+}
+
+func TestScreenFilterErrors(t *testing.T) {
+	tests := []struct {
+		screen Screen
+		pos    int
+	}{
+		{Screen{Filter: "tic <"}, 5},
+		{Screen{Filter: "tic < cheap"}, 6},
+		{Screen{Filter: "fees < 1"}, 0},
+		{Screen{Filter: "category < 'Equity'"}, 9},
+		{Screen{Filter: "add_fee = maybe"}, 10},
+		{Screen{Filter: "manager_id = 3.5"}, 13},
+		{Screen{Filter: "(tic < 1"}, 8},
+		{Screen{Filter: "tic < 1 tc < 1"}, 8},
+		{Screen{Filter: "class = 'A"}, 8},
+		{Screen{Filter: "class = A; DROP TABLE funds"}, 9},
+		{Screen{Sort: "name"}, 0},
+	}
+
+	store := NewMemoryStore()
+	for _, tt := range tests {
+		_, _, err := store.ScreenFundClassesContext(context.Background(), tt.screen, Page{})
+
+		var filterErr *FilterError
+		if !errors.As(err, &filterErr) {
+			t.Errorf("%+v: err = %v, want a *FilterError", tt.screen, err)
+			continue
+		}
+		if filterErr.Pos != tt.pos {
+			t.Errorf("%+v: error at %d (%v), want at %d", tt.screen, filterErr.Pos, err, tt.pos)
+		}
+	}
+}
+
+func TestScreenFilterCompilesToPlaceholders(t *testing.T) {
+	node, err := parseFilter(`fund ~ "x' OR 1=1 --" and tic <= 1.5%`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var args []any
+	got := node.sql(&args)
+
+	want := `(LOWER(f.name) LIKE ? ESCAPE '\' AND lc.tic <= ?)`
+	if got != want {
+		t.Errorf("sql = %s, want %s", got, want)
+	}
+	if len(args) != 2 || args[0] != "%x' or 1=1 --%" || !args[1].(models.Decimal).Equal(*decimal("1.5")) {
+		t.Errorf("args = %v, want the quoted text and 1.5", args)
+	}
+}
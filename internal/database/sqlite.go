@@ -0,0 +1,36 @@
+package database
+
+import (
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteDriver = "sqlite"
+
+func init() {
+	// sqlx does not know the modernc driver name, register its bindvar style.
+	sqlx.BindDriver(sqliteDriver, sqlx.QUESTION)
+}
+
+// sqliteDataSource turns sqlite://path/to/funds.db (or sqlite:funds.db) into
+// a modernc.org/sqlite data source with foreign keys enforced, matching the
+// referential behaviour of the Postgres schema.
+func sqliteDataSource(dsn string) string {
+	path := dsn
+	for _, prefix := range []string{"sqlite3://", "sqlite://", "sqlite3:", "sqlite:"} {
+		if strings.HasPrefix(path, prefix) {
+			path = strings.TrimPrefix(path, prefix)
+			break
+		}
+	}
+
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	return path + separator + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+}
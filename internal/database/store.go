@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+// Store is the set of persistence operations the scraping pipeline relies on.
+// DB implements it against Postgres or SQLite and MemoryStore keeps everything
+// in memory.
+type Store interface {
+	GetAllCISManagersContext(ctx context.Context) ([]*models.CISManager, error)
+	SaveCISManagersContext(ctx context.Context, cisManager []*models.CISManager) error
+
+	GetAllFundsContext(ctx context.Context) ([]*models.Fund, error)
+	SaveFundsContext(ctx context.Context, funds []*models.Fund) error
+	GetFundByNameContext(ctx context.Context, name string) (int, error)
+	GetAllFundNamesContext(ctx context.Context) (map[int]string, error)
+
+	GetFundClassesByFundContext(ctx context.Context, fundID int) ([]*models.FundClass, error)
+	SaveFundClassContext(ctx context.Context, fundClass *models.FundClass) error
+	SaveFundClassCostsContext(ctx context.Context, fundClassCost *models.FundClassCost) error
+	SaveFundClassPriceContext(ctx context.Context, fundClassPrice *models.FundClassPrice) error
+
+	CreateScrapeRunContext(ctx context.Context, run *models.ScrapeRun) error
+	FinishScrapeRunContext(ctx context.Context, run *models.ScrapeRun) error
+	SaveScrapeRunItemContext(ctx context.Context, item *models.ScrapeRunItem) error
+	ListScrapeRunsContext(ctx context.Context, limit int) ([]*models.ScrapeRun, error)
+	GetScrapeRunContext(ctx context.Context, id int) (*models.ScrapeRun, error)
+	GetScrapeRunItemsContext(ctx context.Context, runID int) ([]*models.ScrapeRunItem, error)
+	GetLatestScrapeRunContext(ctx context.Context, mode string) (*models.ScrapeRun, error)
+	SaveScrapeRunCheckpointContext(ctx context.Context, runID int, itemKey string) error
+	GetScrapeRunCheckpointsContext(ctx context.Context, runID int) ([]string, error)
+
+	GetFundNameAliasesContext(ctx context.Context, scrapedName string) ([]*models.FundNameAlias, error)
+	ListFundNameAliasesContext(ctx context.Context, status string) ([]*models.FundNameAlias, error)
+	ProposeFundNameAliasContext(ctx context.Context, scrapedName string, trustNo int, source string) error
+	SetFundNameAliasStatusContext(ctx context.Context, scrapedName string, trustNo int, status string, confirmedBy string) error
+
+	SavePeerRankingsContext(ctx context.Context, date models.Date, rankings []*models.PeerRanking) error
+}
+
+var _ Store = (*DB)(nil)
+
+// ReadStore is the set of queries the read-only API serves from. DB and
+// MemoryStore implement it next to Store.
+type ReadStore interface {
+	ListCISManagersContext(ctx context.Context, page Page) ([]*models.CISManager, int, error)
+
+	ListFundsContext(ctx context.Context, managerID int, page Page) ([]*models.Fund, int, error)
+	GetFundContext(ctx context.Context, trustNo int) (*models.Fund, error)
+
+	GetFundClassContext(ctx context.Context, id int) (*models.FundClass, error)
+	GetFundClassesByFundContext(ctx context.Context, fundID int) ([]*models.FundClass, error)
+	GetLatestFundClassCostsContext(ctx context.Context, fundID int) (map[int]*models.FundClassCost, error)
+	GetFundClassCostsAsOfContext(ctx context.Context, fundClassID int, asOf models.Date) (*models.FundClassCost, error)
+	GetLatestFundClassPricesContext(ctx context.Context, fundID int) (map[int]*models.FundClassPrice, error)
+	ListFundClassPricesContext(ctx context.Context, fundClassID int, from *models.Date, to *models.Date, page Page) ([]*models.FundClassPrice, int, error)
+
+	ScreenFundClassesContext(ctx context.Context, screen Screen, page Page) ([]*models.FundClassSummary, int, error)
+
+	GetLatestPeerRankingsContext(ctx context.Context, fundClassID int) ([]*models.PeerRanking, error)
+	ListPeerRankingsContext(ctx context.Context, fundClassID int, metric string, from *models.Date, to *models.Date, page Page) ([]*models.PeerRanking, int, error)
+}
+
+var _ ReadStore = (*DB)(nil)
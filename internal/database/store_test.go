@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+// TestStoresSaveAlike runs the same saves against a MemoryStore and a
+// migrated SQLite DB, so the in-memory store keeps to the schema's keys.
+func TestStoresSaveAlike(t *testing.T) {
+	db, err := NewDB(&DbConfig{DSN: "sqlite://" + filepath.Join(t.TempDir(), "store.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.MigrateUp(); err != nil {
+		t.Fatal(err)
+	}
+
+	stores := map[string]interface {
+		Store
+		ReadStore
+	}{"memory": NewMemoryStore(), "sqlite": db}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if err := store.SaveCISManagersContext(ctx, []*models.CISManager{{ID: 303, Name: "Allan Gray"}}); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.SaveFundsContext(ctx, []*models.Fund{{TrustNo: 1234, Name: "Allan Gray Balanced Fund", ManagerID: 303}}); err != nil {
+				t.Fatal(err)
+			}
+
+			// A class saved again keeps its ID.
+			class := &models.FundClass{FundID: 1234, ClassName: "Class A", TargetMarket: "Retail"}
+			if err := store.SaveFundClassContext(ctx, class); err != nil {
+				t.Fatal(err)
+			}
+			again := &models.FundClass{FundID: 1234, ClassName: "Class A", TargetMarket: "Retail", AddFee: true}
+			if err := store.SaveFundClassContext(ctx, again); err != nil {
+				t.Fatal(err)
+			}
+			if again.ID != class.ID {
+				t.Errorf("class saved again has ID %d, want %d", again.ID, class.ID)
+			}
+
+			// Costs and prices saved again for a date replace the earlier ones.
+			for _, tic := range []string{"1.30", "1.25"} {
+				cost := &models.FundClassCost{FundClassID: class.ID, TICDate: date("2024-09-30"), TIC: decimal(tic)}
+				if err := store.SaveFundClassCostsContext(ctx, cost); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, nav := range []string{"100.00", "101.50"} {
+				price := &models.FundClassPrice{FundClassID: class.ID, PriceDate: date("2024-10-17"), NAV: decimal(nav)}
+				if err := store.SaveFundClassPriceContext(ctx, price); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Neither store can key costs or prices without a date.
+			if err := store.SaveFundClassCostsContext(ctx, &models.FundClassCost{FundClassID: class.ID, TIC: decimal("9.99")}); err == nil {
+				t.Error("costs without a TIC date saved")
+			}
+			if err := store.SaveFundClassPriceContext(ctx, &models.FundClassPrice{FundClassID: class.ID, NAV: decimal("9.99")}); err == nil {
+				t.Error("price without a date saved")
+			}
+
+			costs, err := store.GetLatestFundClassCostsContext(ctx, 1234)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cost := costs[class.ID]; cost == nil || !cost.TIC.Equal(*decimal("1.25")) {
+				t.Errorf("latest costs = %+v, want a TIC of 1.25", cost)
+			}
+
+			prices, err := store.GetLatestFundClassPricesContext(ctx, 1234)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if price := prices[class.ID]; price == nil || !price.NAV.Equal(*decimal("101.50")) {
+				t.Errorf("latest price = %+v, want a NAV of 101.50", price)
+			}
+		})
+	}
+}
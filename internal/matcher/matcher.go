@@ -0,0 +1,161 @@
+// Package matcher ranks known funds against the fund names printed on the
+// LatestPrices page.
+package matcher
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+const (
+	tokenSetWeight    = 0.4
+	jaroWinklerWeight = 0.3
+	trigramWeight     = 0.3
+)
+
+type Match struct {
+	TrustNo int
+	Name    string
+	Score   float64
+}
+
+type candidate struct {
+	fund   *models.Fund
+	brand  string
+	tokens []string
+	rest   []string
+}
+
+type manager struct {
+	id    int
+	name  string
+	brand string
+}
+
+type Matcher struct {
+	candidates []candidate
+	brands     [][]string
+	managers   []manager
+}
+
+// New indexes funds for matching. Manager names are used to recognise brand
+// prefixes such as "Allan Gray" at the start of fund names.
+func New(funds []*models.Fund, managers []*models.CISManager) *Matcher {
+	m := &Matcher{}
+
+	seen := make(map[string]bool)
+	for _, cisManager := range managers {
+		brand := managerBrand(cisManager.Name)
+		key := strings.Join(brand, " ")
+		m.managers = append(m.managers, manager{id: cisManager.ID, name: Normalize(cisManager.Name), brand: key})
+
+		if len(brand) == 0 || seen[key] {
+			continue
+		}
+		seen[key] = true
+		m.brands = append(m.brands, brand)
+	}
+
+	for _, fund := range funds {
+		tokens := normalizedTokens(fund.Name)
+		brand, rest := splitBrand(tokens, m.brands)
+		m.candidates = append(m.candidates, candidate{fund: fund, brand: brand, tokens: tokens, rest: rest})
+	}
+
+	return m
+}
+
+// ManagerID returns the id of the manager called name, comparing normalized
+// names first and then brands, or 0 when no single manager fits.
+func (m *Matcher) ManagerID(name string) int {
+	normalized := Normalize(name)
+	if normalized == "" {
+		return 0
+	}
+
+	for _, manager := range m.managers {
+		if manager.name == normalized {
+			return manager.id
+		}
+	}
+
+	brand := strings.Join(managerBrand(name), " ")
+	if brand == "" {
+		return 0
+	}
+
+	id := 0
+	for _, manager := range m.managers {
+		if manager.brand != brand {
+			continue
+		}
+		if id != 0 && id != manager.id {
+			return 0
+		}
+		id = manager.id
+	}
+
+	return id
+}
+
+// Rank returns the n best scoring funds for name, best first.
+func (m *Matcher) Rank(name string, n int) []Match {
+	return m.RankManager(name, 0, n)
+}
+
+// RankManager is Rank restricted to the funds of one manager. A managerID of
+// 0 searches all funds.
+func (m *Matcher) RankManager(name string, managerID int, n int) []Match {
+	tokens := normalizedTokens(name)
+	brand, rest := splitBrand(tokens, m.brands)
+
+	matches := make([]Match, 0, len(m.candidates))
+	for _, c := range m.candidates {
+		if managerID != 0 && c.fund.ManagerID != managerID {
+			continue
+		}
+		matches = append(matches, Match{
+			TrustNo: c.fund.TrustNo,
+			Name:    c.fund.Name,
+			Score:   score(tokens, brand, rest, c),
+		})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].TrustNo < matches[j].TrustNo
+	})
+
+	if n < len(matches) {
+		matches = matches[:n]
+	}
+
+	return matches
+}
+
+// score compares the names without their brand prefix when the brands agree
+// or one side has none, and in full otherwise so that same-named funds from
+// different managers stay apart.
+func score(tokens []string, brand string, rest []string, c candidate) float64 {
+	a, b := tokens, c.tokens
+	if brand == "" || c.brand == "" || brand == c.brand {
+		a, b = rest, c.rest
+		if brand == "" && c.brand != "" {
+			a, b = tokens, c.rest
+		}
+	}
+
+	joinedA := strings.Join(a, " ")
+	joinedB := strings.Join(b, " ")
+	if joinedA == joinedB && joinedA != "" {
+		return 1
+	}
+
+	return tokenSetWeight*tokenSetRatio(a, b) +
+		jaroWinklerWeight*jaroWinkler(joinedA, joinedB) +
+		trigramWeight*trigramSimilarity(joinedA, joinedB)
+}
@@ -0,0 +1,163 @@
+package matcher
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"Allan Gray Balanced Fund":                  "allan gray balanced",
+		"Coronation Top 20 Fund":                    "coronation top 20",
+		"Nedgroup Inv Core Diversified Unit Trust":  "nedgroup inv core diversified",
+		"Stanlib Balanced Unit Trust Fund":          "stanlib balanced",
+		"PSG Flexible Fund of Funds":                "psg flexible",
+		"Sygnia Skeleton Balanced 70 FoF":           "sygnia skeleton balanced 70",
+		"Old Mutual Global Equity UT":               "old mutual global equity",
+		"M&G Balanced Fund":                         "m and g balanced",
+		"Prudential Inflation Plus & Income Fund":   "prudential inflation plus and income",
+		"Ninety One (Opportunity) Fund - A":         "ninety one opportunity fund a",
+		"  Satrix   40  Portfolio, Class B1 ":       "satrix 40 portfolio class b1",
+		"Fund":                                      "fund",
+		"Funds Fund":                                "funds",
+		"Absa Property Equity Fund of Funds Fund":   "absa property equity",
+		"Foord Flexible Fund of Funds (Unit Trust)": "foord flexible",
+	}
+
+	for name, want := range tests {
+		if got := Normalize(name); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"martha", "marhta", 0.9611},
+		{"dwayne", "duane", 0.84},
+		{"dixon", "dicksonx", 0.8133},
+		{"balanced", "balanced", 1},
+		{"abc", "xyz", 0},
+		{"", "abc", 0},
+	}
+
+	for _, tt := range tests {
+		if got := jaroWinkler(tt.a, tt.b); math.Abs(got-tt.want) > 0.0001 {
+			t.Errorf("jaroWinkler(%q, %q) = %.4f, want %.4f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestTrigramSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"night", "nacht", 1.0 / 3},
+		{"balanced", "balanced", 1},
+		{"equity income", "income equity", 1},
+		{"abc", "xyz", 0},
+		{"", "abc", 0},
+	}
+
+	for _, tt := range tests {
+		if got := trigramSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 0.0001 {
+			t.Errorf("trigramSimilarity(%q, %q) = %.4f, want %.4f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSplitBrand(t *testing.T) {
+	brands := [][]string{{"allan"}, {"allan", "gray"}, {"coronation"}}
+
+	tests := []struct {
+		name  string
+		brand string
+		rest  string
+	}{
+		{"Allan Gray Balanced Fund", "allan gray", "balanced"},
+		{"Allan Smith Equity", "allan", "smith equity"},
+		{"Coronation Top 20", "coronation", "top 20"},
+		// A name that is only a brand keeps it, so it is never compared as empty.
+		{"Coronation Fund", "", "coronation"},
+		{"Balanced Plus Fund", "", "balanced plus"},
+	}
+
+	for _, tt := range tests {
+		tokens := normalizedTokens(tt.name)
+		brand, rest := splitBrand(tokens, brands)
+		if rest := strings.Join(rest, " "); brand != tt.brand || rest != tt.rest {
+			t.Errorf("splitBrand(%q) = %q, %q, want %q, %q", tt.name, brand, rest, tt.brand, tt.rest)
+		}
+	}
+}
+
+func testMatcher() *Matcher {
+	managers := []*models.CISManager{
+		{ID: 1, Name: "Allan Gray Unit Trust Management (RF) (Pty) Ltd"},
+		{ID: 2, Name: "Coronation Management Company (RF) (Pty) Ltd"},
+		{ID: 3, Name: "Sanlam Collective Investments (RF) (Pty) Ltd"},
+		{ID: 4, Name: "Sanlam Unit Trusts Ltd"},
+	}
+	funds := []*models.Fund{
+		{TrustNo: 10, Name: "Allan Gray Balanced Plus Fund", ManagerID: 1},
+		{TrustNo: 11, Name: "Allan Gray Equity Fund", ManagerID: 1},
+		{TrustNo: 20, Name: "Coronation Balanced Plus Fund", ManagerID: 2},
+		{TrustNo: 21, Name: "Coronation Top 20 Fund", ManagerID: 2},
+	}
+	return New(funds, managers)
+}
+
+func TestManagerID(t *testing.T) {
+	m := testMatcher()
+
+	tests := map[string]int{
+		"Allan Gray Unit Trust Management (RF) (Pty) Ltd": 1,
+		"ALLAN GRAY":               1,
+		"Coronation Fund Managers": 2,
+		// Two managers share the Sanlam brand, so it names neither.
+		"Sanlam": 0,
+		"Sanlam Collective Investments (RF) (Pty) Ltd": 3,
+		"Unknown Asset Managers":                       0,
+		"":                                             0,
+	}
+
+	for name, want := range tests {
+		if got := m.ManagerID(name); got != want {
+			t.Errorf("ManagerID(%q) = %d, want %d", name, got, want)
+		}
+	}
+}
+
+func TestRankManager(t *testing.T) {
+	m := testMatcher()
+
+	best := m.Rank("Allan Gray Equity", 1)
+	if len(best) != 1 || best[0].TrustNo != 11 || best[0].Score != 1 {
+		t.Errorf("Rank(Allan Gray Equity) = %+v, want fund 11 at 1", best)
+	}
+
+	// The brand keeps same-named funds of different managers apart.
+	branded := m.Rank("Coronation Balanced Plus", 2)
+	if branded[0].TrustNo != 20 || branded[0].Score != 1 || branded[1].Score >= 1 {
+		t.Errorf("Rank(Coronation Balanced Plus) = %+v, want fund 20 ahead", branded)
+	}
+
+	// Without a brand or manager the name fits both managers' funds equally,
+	// which the caller has to treat as ambiguous.
+	tied := m.Rank("Balanced Plus Fund", 2)
+	if len(tied) != 2 || tied[0].Score != 1 || tied[1].Score != 1 {
+		t.Errorf("Rank(Balanced Plus Fund) = %+v, want a tie at 1", tied)
+	}
+
+	managed := m.RankManager("Balanced Plus Fund", 2, 5)
+	if len(managed) != 2 || managed[0].TrustNo != 20 || managed[0].Score != 1 {
+		t.Errorf("RankManager(Balanced Plus Fund, 2) = %+v, want only Coronation's funds, 20 first", managed)
+	}
+}
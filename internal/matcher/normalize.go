@@ -0,0 +1,99 @@
+package matcher
+
+import (
+	"strings"
+	"unicode"
+)
+
+// nameSuffixes are dropped from the end of fund names, longest first, since
+// the site and the HistPriceLookUp list disagree on whether they are present.
+var nameSuffixes = [][]string{
+	{"fund", "of", "funds"},
+	{"unit", "trust", "fund"},
+	{"unit", "trust"},
+	{"fund"},
+	{"fof"},
+	{"ut"},
+}
+
+// brandStopWords end the brand part of a manager name, so that
+// "Allan Gray Unit Trust Management (RF) Ltd" has the brand "allan gray".
+var brandStopWords = map[string]bool{
+	"unit": true, "collective": true, "management": true, "managers": true, "manager": true,
+	"fund": true, "funds": true, "asset": true, "assets": true, "investment": true,
+	"investments": true, "rf": true, "pty": true, "ltd": true, "limited": true,
+	"company": true, "co": true, "cis": true, "portfolios": true,
+}
+
+// Normalize lowercases a fund name, spells out "&", strips punctuation and
+// drops trailing "Fund", "Unit Trust" and "FoF" style suffixes.
+func Normalize(name string) string {
+	return strings.Join(normalizedTokens(name), " ")
+}
+
+func normalizedTokens(name string) []string {
+	name = strings.ToLower(name)
+	name = strings.ReplaceAll(name, "&", " and ")
+
+	tokens := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for {
+		trimmed := false
+		for _, suffix := range nameSuffixes {
+			if len(tokens) > len(suffix) && hasSuffixTokens(tokens, suffix) {
+				tokens = tokens[:len(tokens)-len(suffix)]
+				trimmed = true
+				break
+			}
+		}
+		if !trimmed {
+			return tokens
+		}
+	}
+}
+
+func hasSuffixTokens(tokens []string, suffix []string) bool {
+	offset := len(tokens) - len(suffix)
+	for i, token := range suffix {
+		if tokens[offset+i] != token {
+			return false
+		}
+	}
+	return true
+}
+
+// managerBrand returns the leading words of a manager name that fund names
+// are usually prefixed with.
+func managerBrand(managerName string) []string {
+	var brand []string
+	for _, token := range normalizedTokens(managerName) {
+		if brandStopWords[token] {
+			break
+		}
+		brand = append(brand, token)
+	}
+	return brand
+}
+
+// splitBrand removes the longest known brand prefix from tokens.
+func splitBrand(tokens []string, brands [][]string) (string, []string) {
+	var best []string
+	for _, brand := range brands {
+		if len(brand) > len(best) && len(brand) < len(tokens) && hasPrefixTokens(tokens, brand) {
+			best = brand
+		}
+	}
+
+	return strings.Join(best, " "), tokens[len(best):]
+}
+
+func hasPrefixTokens(tokens []string, prefix []string) bool {
+	for i, token := range prefix {
+		if tokens[i] != token {
+			return false
+		}
+	}
+	return true
+}
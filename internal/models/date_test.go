@@ -0,0 +1,78 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestNewDateRejectsImpossibleDates(t *testing.T) {
+	if _, err := NewDate(2024, time.April, 31); err == nil {
+		t.Error("NewDate(2024-04-31) succeeded, want an error")
+	}
+
+	if _, err := NewDate(2023, time.February, 29); err == nil {
+		t.Error("NewDate(2023-02-29) succeeded, want an error")
+	}
+
+	date, err := NewDate(2024, time.February, 29)
+	if err != nil {
+		t.Fatalf("NewDate(2024-02-29): %v", err)
+	}
+	if got := date.String(); got != "2024-02-29" {
+		t.Errorf("String() = %q, want 2024-02-29", got)
+	}
+}
+
+func TestDateScan(t *testing.T) {
+	want := Date{Year: 2024, Month: time.February, Day: 1}
+
+	for _, src := range []any{
+		time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+		"2024-02-01",
+		[]byte("2024-02-01"),
+		"2024-02-01T00:00:00Z",
+	} {
+		var got Date
+		if err := got.Scan(src); err != nil {
+			t.Errorf("Scan(%#v): %v", src, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Scan(%#v) = %v, want %v", src, got, want)
+		}
+	}
+
+	var invalid Date
+	if err := invalid.Scan("2024-2-1"); err == nil {
+		t.Error("Scan(\"2024-2-1\") succeeded, want an error")
+	}
+}
+
+func TestDateValueAndJSON(t *testing.T) {
+	date := Date{Year: 2024, Month: time.February, Day: 1}
+
+	value, err := date.Value()
+	if err != nil || value != "2024-02-01" {
+		t.Errorf("Value() = %v, %v, want 2024-02-01", value, err)
+	}
+
+	if value, err := (Date{}).Value(); err != nil || value != nil {
+		t.Errorf("zero Value() = %v, %v, want NULL", value, err)
+	}
+
+	zero := date
+	if err := zero.Scan(nil); err != nil || !zero.IsZero() {
+		t.Errorf("Scan(nil) = %v, %v, want the zero Date", zero, err)
+	}
+
+	data, err := json.Marshal(date)
+	if err != nil || string(data) != `"2024-02-01"` {
+		t.Errorf("Marshal = %s, %v, want \"2024-02-01\"", data, err)
+	}
+
+	var decoded Date
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded != date {
+		t.Errorf("Unmarshal(%s) = %v, %v, want %v", data, decoded, err, date)
+	}
+}
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxDecimalScale bounds the digits after the point so that coefficients of
+// NAVs and percentages always fit in an int64.
+const maxDecimalScale = 9
+
+// Decimal is an exact base 10 number such as a NAV or a fee percentage. It
+// keeps the digits it was parsed with, so 124.50 stays 124.50 rather than
+// becoming the nearest float64.
+type Decimal struct {
+	coef  int64
+	scale int32
+}
+
+// NewDecimal returns coef × 10^-scale, so NewDecimal(12450, 2) is 124.50.
+func NewDecimal(coef int64, scale int32) Decimal {
+	return Decimal{coef: coef, scale: scale}
+}
+
+// ParseDecimal parses a plain decimal such as "-1.25" or "124.5731". Exponents,
+// thousands separators and percent signs are rejected.
+func ParseDecimal(s string) (Decimal, error) {
+	original := s
+
+	negative := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		negative = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", original)
+	}
+
+	if len(fraction) > maxDecimalScale {
+		return Decimal{}, fmt.Errorf("invalid decimal %q: more than %d decimal places", original, maxDecimalScale)
+	}
+
+	digits := whole + fraction
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", original)
+		}
+	}
+
+	coef, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("invalid decimal %q: %w", original, err)
+	}
+
+	if negative {
+		coef = -coef
+	}
+
+	return Decimal{coef: coef, scale: int32(len(fraction))}, nil
+}
+
+// DecimalFromFloat converts f using the shortest representation that reads
+// back as f, which is what SQLite hands back for NUMERIC columns. Floats that
+// need more than maxDecimalScale places are rounded.
+func DecimalFromFloat(f float64) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, fmt.Errorf("invalid decimal %v", f)
+	}
+
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if _, fraction, _ := strings.Cut(s, "."); len(fraction) > maxDecimalScale {
+		s = strings.TrimRight(strconv.FormatFloat(f, 'f', maxDecimalScale, 64), "0")
+	}
+
+	return ParseDecimal(s)
+}
+
+func (d Decimal) String() string {
+	digits := strconv.FormatInt(d.coef, 10)
+	sign := ""
+	if d.coef < 0 {
+		sign = "-"
+		digits = digits[1:]
+	}
+
+	if d.scale <= 0 {
+		return sign + digits + strings.Repeat("0", int(-d.scale))
+	}
+
+	if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+
+	point := len(digits) - int(d.scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// Float64 returns the nearest float64, for arithmetic where exactness no
+// longer matters such as returns and volatility.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+func (d Decimal) IsZero() bool {
+	return d.coef == 0
+}
+
+// Cmp returns -1, 0 or +1 as d is less than, equal to or greater than other.
+// Trailing zeros do not matter, so 1.50 equals 1.5.
+func (d Decimal) Cmp(other Decimal) int {
+	return d.rat().Cmp(other.rat())
+}
+
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+func (d Decimal) rat() *big.Rat {
+	r := new(big.Rat).SetInt64(d.coef)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs32(d.scale))), nil)
+	if d.scale >= 0 {
+		return r.Quo(r, new(big.Rat).SetInt(scale))
+	}
+	return r.Mul(r, new(big.Rat).SetInt(scale))
+}
+
+func abs32(n int32) int32 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Scan accepts NUMERIC values as Postgres ([]byte) and SQLite (float64 or
+// int64) return them.
+func (d *Decimal) Scan(src any) error {
+	var err error
+	switch value := src.(type) {
+	case []byte:
+		*d, err = ParseDecimal(string(value))
+	case string:
+		*d, err = ParseDecimal(value)
+	case float64:
+		*d, err = DecimalFromFloat(value)
+	case int64:
+		*d = Decimal{coef: value}
+	default:
+		return fmt.Errorf("cannot scan %T into Decimal", src)
+	}
+	return err
+}
+
+// Value writes the decimal as text so no float conversion happens on the way in.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// MarshalJSON writes the decimal as a JSON number with its original digits.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	parsed, err := ParseDecimal(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
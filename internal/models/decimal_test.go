@@ -0,0 +1,87 @@
+package models
+
+import "testing"
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"124.5731", "124.5731"},
+		{"124.50", "124.50"},
+		{"0.00", "0.00"},
+		{"-1.25", "-1.25"},
+		{"-0.05", "-0.05"},
+		{".5", "0.5"},
+		{"+3", "3"},
+		{"10.", "10"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDecimal(tt.in)
+		if err != nil {
+			t.Errorf("ParseDecimal(%q): %v", tt.in, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseDecimal(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "-", ".", "n/a", "1 204.33", "1e3", "1.2.3", "0.1234567891"} {
+		if got, err := ParseDecimal(in); err == nil {
+			t.Errorf("ParseDecimal(%q) = %s, want an error", in, got)
+		}
+	}
+}
+
+func TestDecimalCmp(t *testing.T) {
+	a, _ := ParseDecimal("1.50")
+	b, _ := ParseDecimal("1.5")
+	c, _ := ParseDecimal("1.51")
+
+	if !a.Equal(b) {
+		t.Errorf("%s should equal %s", a, b)
+	}
+	if a.Cmp(c) != -1 || c.Cmp(a) != 1 {
+		t.Errorf("%s should be less than %s", a, c)
+	}
+}
+
+func TestDecimalScan(t *testing.T) {
+	tests := []struct {
+		src  any
+		want string
+	}{
+		{[]byte("124.5731"), "124.5731"},
+		{"0.21", "0.21"},
+		{124.5731, "124.5731"},
+		{float64(1) / 3, "0.333333333"},
+		{int64(3), "3"},
+	}
+
+	for _, tt := range tests {
+		var got Decimal
+		if err := got.Scan(tt.src); err != nil {
+			t.Errorf("Scan(%#v): %v", tt.src, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("Scan(%#v) = %s, want %s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalValueKeepsDigits(t *testing.T) {
+	nav := NewDecimal(12457, 2)
+
+	value, err := nav.Value()
+	if err != nil || value != "124.57" {
+		t.Errorf("Value() = %v, %v, want 124.57", value, err)
+	}
+
+	data, err := nav.MarshalJSON()
+	if err != nil || string(data) != "124.57" {
+		t.Errorf("MarshalJSON() = %s, %v, want 124.57", data, err)
+	}
+}
@@ -5,7 +5,7 @@ type FundClass struct {
 	FundID       int      `db:"fund_id"`
 	ClassName    string   `db:"class_name"`
 	AddFee       bool     `db:"add_fee"`
-	MaxInitFee   *float64 `db:"max_init_fee"`
+	MaxInitFee   *Decimal `db:"max_init_fee"`
 	Category     string   `db:"category"`
 	TargetMarket string   `db:"target_market"`
 
@@ -15,16 +15,36 @@ type FundClass struct {
 type FundClassCost struct {
 	ID          int      `db:"id"`
 	FundClassID int      `db:"fund_class_id"`
-	TICDate     *string  `db:"tic_date"`
-	TERPerfComp *float64 `db:"ter_perf_comp"`
-	TER         *float64 `db:"ter"`
-	TC          *float64 `db:"tc"`
-	TIC         *float64 `db:"tic"`
+	TICDate     *Date    `db:"tic_date"`
+	TERPerfComp *Decimal `db:"ter_perf_comp"`
+	TER         *Decimal `db:"ter"`
+	TC          *Decimal `db:"tc"`
+	TIC         *Decimal `db:"tic"`
 }
 
 type FundClassPrice struct {
 	ID          int      `db:"id"`
 	FundClassID int      `db:"fund_class_id"`
-	PriceDate   *string  `db:"price_date"`
-	NAV         *float64 `db:"nav"`
+	PriceDate   *Date    `db:"price_date"`
+	NAV         *Decimal `db:"nav"`
+}
+
+// FundClassSummary is a fund class joined with its fund, manager and latest
+// costs, as the screener returns it.
+type FundClassSummary struct {
+	FundClassID  int      `db:"fund_class_id"`
+	TrustNo      int      `db:"trust_no"`
+	FundName     string   `db:"fund_name"`
+	ClassName    string   `db:"class_name"`
+	ManagerID    int      `db:"manager_id"`
+	ManagerName  string   `db:"manager_name"`
+	Category     string   `db:"category"`
+	TargetMarket string   `db:"target_market"`
+	AddFee       bool     `db:"add_fee"`
+	MaxInitFee   *Decimal `db:"max_init_fee"`
+	CostsDate    *Date    `db:"costs_date"`
+	TERPerfComp  *Decimal `db:"ter_perf_comp"`
+	TER          *Decimal `db:"ter"`
+	TC           *Decimal `db:"tc"`
+	TIC          *Decimal `db:"tic"`
 }
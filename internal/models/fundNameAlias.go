@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type FundNameAlias struct {
+	ID          int       `db:"id"`
+	ScrapedName string    `db:"scraped_name"`
+	TrustNo     int       `db:"trust_no"`
+	Source      string    `db:"source"`
+	Status      string    `db:"status"`
+	ConfirmedBy *string   `db:"confirmed_by"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// PeerRanking places a fund class among the classes of its category on one
+// metric of a daily snapshot. Rank 1, quartile 1 and percentile 1 are the
+// best, for returns the highest and for costs the lowest. Value is a
+// fraction for returns and a percentage for costs, as fund_class_costs
+// stores them.
+type PeerRanking struct {
+	ID           int     `db:"id"`
+	SnapshotDate Date    `db:"snapshot_date"`
+	FundClassID  int     `db:"fund_class_id"`
+	Category     string  `db:"category"`
+	Metric       string  `db:"metric"`
+	Value        float64 `db:"value"`
+	Rank         int     `db:"rank"`
+	Peers        int     `db:"peers"`
+	Quartile     int     `db:"quartile"`
+	Percentile   int     `db:"percentile"`
+}
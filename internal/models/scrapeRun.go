@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+type ScrapeRun struct {
+	ID            int        `db:"id"`
+	Mode          string     `db:"mode"`
+	Status        string     `db:"status"`
+	StartedAt     time.Time  `db:"started_at"`
+	FinishedAt    *time.Time `db:"finished_at"`
+	Matched       int        `db:"matched"`
+	Unmatched     int        `db:"unmatched"`
+	SavedManagers int        `db:"saved_managers"`
+	SavedFunds    int        `db:"saved_funds"`
+	SavedPrices   int        `db:"saved_prices"`
+	SavedCosts    int        `db:"saved_costs"`
+	Warnings      int        `db:"warnings"`
+	RowsParsed    int        `db:"rows_parsed"`
+	RowsDropped   int        `db:"rows_dropped"`
+	Error         *string    `db:"error"`
+	ResumedFrom   *int       `db:"resumed_from"`
+}
+
+type ScrapeRunItem struct {
+	ID      int     `db:"id"`
+	RunID   int     `db:"run_id"`
+	Item    string  `db:"item"`
+	Status  string  `db:"status"`
+	Message *string `db:"message"`
+}
@@ -0,0 +1,118 @@
+package scraper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var ErrNotArchived = errors.New("response not found in archive")
+
+// volatileFormFields change on every page load without changing what the site
+// returns, so they are left out of the body hash to keep replays stable.
+var volatileFormFields = []string{"__VIEWSTATE", "__VIEWSTATEGENERATOR", "__EVENTVALIDATION"}
+
+type ArchiveEntry struct {
+	Method     string    `json:"method"`
+	URL        string    `json:"url"`
+	BodyHash   string    `json:"body_hash"`
+	StatusCode int       `json:"status_code"`
+	Body       []byte    `json:"body"`
+	FetchedAt  time.Time `json:"fetched_at"`
+}
+
+// Archive stores one JSON file per distinct request (method, URL and form
+// body hash) in a directory. Later responses for the same request replace
+// earlier ones.
+type Archive struct {
+	dir string
+}
+
+func NewArchive(dir string) (*Archive, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating archive directory: %w", err)
+	}
+
+	return &Archive{dir: dir}, nil
+}
+
+func (a *Archive) Save(entry *ArchiveEntry) error {
+	contents, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding archive entry: %w", err)
+	}
+
+	path := a.path(entry.Method, entry.URL, entry.BodyHash)
+
+	// Workers can fetch the same URL at once, so each writes its own temporary
+	// file and the last rename wins.
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating archive entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(contents); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing archive entry: %w", err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing archive entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing archive entry: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error saving archive entry: %w", err)
+	}
+
+	return nil
+}
+
+func (a *Archive) Load(method string, rawURL string, body []byte) (*ArchiveEntry, error) {
+	contents, err := os.ReadFile(a.path(method, rawURL, HashRequestBody(body)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotArchived, method, rawURL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading archive entry: %w", err)
+	}
+
+	var entry ArchiveEntry
+	if err := json.Unmarshal(contents, &entry); err != nil {
+		return nil, fmt.Errorf("error decoding archive entry: %w", err)
+	}
+
+	return &entry, nil
+}
+
+func (a *Archive) path(method string, rawURL string, bodyHash string) string {
+	key := sha256.Sum256([]byte(method + " " + rawURL + " " + bodyHash))
+	return filepath.Join(a.dir, hex.EncodeToString(key[:16])+".json")
+}
+
+// HashRequestBody hashes a form encoded request body, ignoring the ASP.NET
+// state fields.
+func HashRequestBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	if values, err := url.ParseQuery(string(body)); err == nil {
+		for _, field := range volatileFormFields {
+			values.Del(field)
+		}
+		body = []byte(values.Encode())
+	}
+
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
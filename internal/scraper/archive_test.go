@@ -0,0 +1,119 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	archive, err := NewArchive(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := []byte(url.Values{"__VIEWSTATE": {"abc"}, "ctl00$Manager": {"0303"}}.Encode())
+	saved := &ArchiveEntry{
+		Method:     "POST",
+		URL:        "https://example.com/LatestPrices.aspx",
+		BodyHash:   HashRequestBody(body),
+		StatusCode: 200,
+		Body:       []byte("<table></table>"),
+		FetchedAt:  time.Date(2024, 10, 17, 8, 0, 0, 0, time.UTC),
+	}
+	if err := archive.Save(saved); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := archive.Load("POST", saved.URL, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(loaded.Body) != string(saved.Body) || loaded.StatusCode != 200 || !loaded.FetchedAt.Equal(saved.FetchedAt) {
+		t.Errorf("loaded %+v, want %+v", loaded, saved)
+	}
+
+	if _, err := archive.Load("GET", saved.URL, body); !errors.Is(err, ErrNotArchived) {
+		t.Errorf("other method: err = %v, want ErrNotArchived", err)
+	}
+}
+
+func TestArchiveParallelSaves(t *testing.T) {
+	dir := t.TempDir()
+	archive, err := NewArchive(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Workers fetching the same URL save the same entry at once.
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- archive.Save(&ArchiveEntry{
+				Method:     "GET",
+				URL:        "https://example.com/Managers.aspx",
+				StatusCode: 200,
+				Body:       []byte("<table></table>"),
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	loaded, err := archive.Load("GET", "https://example.com/Managers.aspx", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(loaded.Body) != "<table></table>" {
+		t.Errorf("loaded body %q", loaded.Body)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("archive holds %d files, want the one entry and no temporary files", len(files))
+	}
+}
+
+func TestHashRequestBodyIgnoresPageState(t *testing.T) {
+	first := url.Values{"__VIEWSTATE": {"one"}, "__EVENTVALIDATION": {"a"}, "ctl00$Manager": {"0303"}}
+	second := url.Values{"__VIEWSTATE": {"two"}, "__EVENTVALIDATION": {"b"}, "ctl00$Manager": {"0303"}}
+	other := url.Values{"__VIEWSTATE": {"one"}, "__EVENTVALIDATION": {"a"}, "ctl00$Manager": {"0037"}}
+
+	if HashRequestBody([]byte(first.Encode())) != HashRequestBody([]byte(second.Encode())) {
+		t.Error("bodies differing only in page state hash differently")
+	}
+	if HashRequestBody([]byte(first.Encode())) == HashRequestBody([]byte(other.Encode())) {
+		t.Error("bodies for different managers hash the same")
+	}
+	if HashRequestBody(nil) != "" {
+		t.Errorf("empty body hash = %q, want none", HashRequestBody(nil))
+	}
+}
+
+func TestReplayMissingEntry(t *testing.T) {
+	archive, err := NewArchive(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient(WithReplay(archive))
+	if _, err := client.GetContext(context.Background(), "https://example.com/Managers.aspx"); !errors.Is(err, ErrNotArchived) {
+		t.Errorf("err = %v, want ErrNotArchived", err)
+	}
+}
@@ -1,11 +1,13 @@
 package scraper
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
-	"strings"
 	"time"
 )
 
@@ -13,6 +15,10 @@ type Client struct {
 	httpClient *http.Client
 	retries    int
 	userAgent  string
+	recorder   *Archive
+	replay     *Archive
+	limiter    *hostLimiter
+	politeness PolitenessPolicy
 }
 
 type ClientOption func(*Client)
@@ -35,6 +41,36 @@ func WithTimeout(timeout time.Duration) ClientOption {
 	}
 }
 
+// WithRecording writes every response the client receives to archive.
+func WithRecording(archive *Archive) ClientOption {
+	return func(c *Client) {
+		c.recorder = archive
+	}
+}
+
+// WithReplay serves every request from archive without touching the network.
+func WithReplay(archive *Archive) ClientOption {
+	return func(c *Client) {
+		c.replay = archive
+	}
+}
+
+// WithRateLimit allows at most requestsPerSecond requests per host, with
+// bursts of up to burst requests. Retries count against the limit.
+func WithRateLimit(requestsPerSecond float64, burst int) ClientOption {
+	return func(c *Client) {
+		if requestsPerSecond > 0 {
+			c.limiter = newHostLimiter(requestsPerSecond, burst)
+		}
+	}
+}
+
+func WithPoliteness(policy PolitenessPolicy) ClientOption {
+	return func(c *Client) {
+		c.politeness = policy
+	}
+}
+
 const defaultUserAgent = "placeholder"
 const defaultRetries = 3
 const defaultTimeout = 30 * time.Second
@@ -44,8 +80,9 @@ func NewClient(options ...ClientOption) *Client {
 		httpClient: &http.Client{
 			Timeout: defaultTimeout,
 		},
-		retries:   defaultRetries,
-		userAgent: defaultUserAgent,
+		retries:    defaultRetries,
+		userAgent:  defaultUserAgent,
+		politeness: defaultPoliteness,
 	}
 
 	for _, option := range options {
@@ -56,7 +93,12 @@ func NewClient(options ...ClientOption) *Client {
 }
 
 func (c *Client) Post(url string, formData url.Values) ([]byte, error) {
-	req, err := http.NewRequest("POST", url, strings.NewReader(formData.Encode()))
+	return c.PostContext(context.Background(), url, formData)
+}
+
+func (c *Client) PostContext(ctx context.Context, url string, formData url.Values) ([]byte, error) {
+	body := []byte(formData.Encode())
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 
 	if err != nil {
 		return nil, err
@@ -66,11 +108,15 @@ func (c *Client) Post(url string, formData url.Values) ([]byte, error) {
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Referer", url)
 
-	return c.doRequest(req)
+	return c.doRequest(req, body)
 }
 
 func (c *Client) Get(url string) ([]byte, error) {
-	req, err := http.NewRequest("GET", url, nil)
+	return c.GetContext(context.Background(), url)
+}
+
+func (c *Client) GetContext(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	if err != nil {
 		return nil, err
@@ -78,40 +124,75 @@ func (c *Client) Get(url string) ([]byte, error) {
 
 	req.Header.Set("User-Agent", c.userAgent)
 
-	return c.doRequest(req)
+	return c.doRequest(req, nil)
 }
 
-func (c *Client) doRequest(req *http.Request) ([]byte, error) {
+func (c *Client) doRequest(req *http.Request, body []byte) ([]byte, error) {
+	if c.replay != nil {
+		return c.replayRequest(req, body)
+	}
+
 	var lastErr error
 	var lastStatusCode int
 
 	for i := 0; i < c.retries; i++ {
+		if i > 0 && req.GetBody != nil {
+			reqBody, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = reqBody
+		}
+
+		if c.limiter != nil {
+			if err := c.limiter.wait(req.Context(), req.URL.Host); err != nil {
+				return nil, err
+			}
+		}
+
 		resp, err := c.httpClient.Do(req)
 
 		if err != nil {
+			if ctxErr := req.Context().Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+
 			lastErr = err
-			if i < c.retries {
-				time.Sleep(time.Duration(i+1) * time.Second)
-				continue
+			if i < c.retries-1 {
+				if err := sleepContext(req.Context(), c.politeness.backoff(i)); err != nil {
+					return nil, err
+				}
 			}
-			break
+			continue
 		}
-		defer resp.Body.Close()
 
-		lastStatusCode = resp.StatusCode
+		bytes, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
 
-		if resp.StatusCode == http.StatusOK {
-			bytes, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
 
-			if err != nil {
-				return nil, fmt.Errorf("failed to read response body: %w", err)
-			}
+		lastErr = nil
+		lastStatusCode = resp.StatusCode
+		c.record(req, body, resp.StatusCode, bytes)
 
+		if resp.StatusCode == http.StatusOK {
 			return bytes, nil
 		}
 
+		if !isRetryableStatus(resp.StatusCode) {
+			return nil, fmt.Errorf("failed to fetch %s: status %d", req.URL, resp.StatusCode)
+		}
+
 		if i < c.retries-1 {
-			time.Sleep(time.Duration(i+1) * time.Second)
+			wait, ok := c.politeness.retryAfter(resp, time.Now())
+			if !ok {
+				wait = c.politeness.backoff(i)
+			}
+			if err := sleepContext(req.Context(), wait); err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -120,3 +201,40 @@ func (c *Client) doRequest(req *http.Request) ([]byte, error) {
 	}
 	return nil, fmt.Errorf("failed to fetch after %d retries: final status %d", c.retries, lastStatusCode)
 }
+
+func (c *Client) replayRequest(req *http.Request, body []byte) ([]byte, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	entry, err := c.replay.Load(req.Method, req.URL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("archived response for %s %s has status %d", req.Method, req.URL, entry.StatusCode)
+	}
+
+	return entry.Body, nil
+}
+
+func (c *Client) record(req *http.Request, body []byte, statusCode int, respBody []byte) {
+	if c.recorder == nil {
+		return
+	}
+
+	entry := &ArchiveEntry{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		BodyHash:   HashRequestBody(body),
+		StatusCode: statusCode,
+		Body:       respBody,
+		FetchedAt:  time.Now(),
+	}
+
+	if err := c.recorder.Save(entry); err != nil {
+		// The archive is a convenience for offline re-runs, it must not fail a scrape.
+		log.Printf("failed to archive response for %s %s: %s\n", req.Method, req.URL, err)
+	}
+}
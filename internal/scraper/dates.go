@@ -0,0 +1,140 @@
+package scraper
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
+)
+
+// DefaultCenturyPivot makes two digit years 00-50 mean 2000-2050 and 51-99
+// mean 1951-1999.
+const DefaultCenturyPivot = 50
+
+// DateParser parses the dates printed on the ASISA pages. The site is not
+// consistent, so it accepts:
+//
+//	2024-10-17, 2024/10/17          ISO year first
+//	17/10/2024, 17/10/24, 17-10-2024 day first, South African order
+//	17 Oct 2024, 17 October 2024    day, month name, year
+//	Oct24, Oct 2024, October 2024   month and year, read as the 1st
+//
+// Month names may be abbreviated or in full and in any case.
+type DateParser struct {
+	// CenturyPivot is the last two digit year read as 20xx, later ones are
+	// 19xx. 0 uses DefaultCenturyPivot, so the zero DateParser is usable.
+	CenturyPivot int
+}
+
+// DefaultDateParser uses DefaultCenturyPivot.
+var DefaultDateParser = DateParser{CenturyPivot: DefaultCenturyPivot}
+
+// NewDateParser returns a DateParser reading two digit years up to
+// centuryPivot as 20xx. The pivot must be between 1 and 99, as 0 is taken
+// by the zero DateParser to mean DefaultCenturyPivot.
+func NewDateParser(centuryPivot int) (DateParser, error) {
+	if centuryPivot < 1 || centuryPivot > 99 {
+		return DateParser{}, fmt.Errorf("century pivot %d is not between 1 and 99", centuryPivot)
+	}
+	return DateParser{CenturyPivot: centuryPivot}, nil
+}
+
+// DateError reports a date that is in none of the known formats or does not
+// exist on the calendar.
+type DateError struct {
+	Value  string
+	Reason string
+}
+
+func (e *DateError) Error() string {
+	return fmt.Sprintf("invalid date %q: %s", e.Value, e.Reason)
+}
+
+var (
+	isoDatePattern      = regexp.MustCompile(`^(\d{4})[-/](\d{1,2})[-/](\d{1,2})$`)
+	numericDatePattern  = regexp.MustCompile(`^(\d{1,2})[-/.](\d{1,2})[-/.](\d{2}|\d{4})$`)
+	namedDatePattern    = regexp.MustCompile(`^(\d{1,2})[\s-]+([A-Za-z]+)\.?[\s-]+(\d{2}|\d{4})$`)
+	namedMonthPattern   = regexp.MustCompile(`^([A-Za-z]+)\.?[\s-]*(\d{2}|\d{4})$`)
+	monthsByName        = make(map[string]time.Month, 24)
+	notAvailableMarkers = map[string]bool{"": true, "n/a": true, "-": true}
+)
+
+func init() {
+	for month := time.January; month <= time.December; month++ {
+		name := strings.ToLower(month.String())
+		monthsByName[name] = month
+		monthsByName[name[:3]] = month
+	}
+	monthsByName["sept"] = time.September
+}
+
+// Parse returns nil and no error for the "n/a" and blank cells the site uses
+// for missing dates, and a *DateError for anything it cannot read.
+func (p DateParser) Parse(value string) (*models.Date, error) {
+	value = strings.TrimSpace(value)
+	if notAvailableMarkers[strings.ToLower(value)] {
+		return nil, nil
+	}
+
+	var year, day int
+	var month time.Month
+
+	switch {
+	case isoDatePattern.MatchString(value):
+		parts := isoDatePattern.FindStringSubmatch(value)
+		year, month, day = atoi(parts[1]), time.Month(atoi(parts[2])), atoi(parts[3])
+	case numericDatePattern.MatchString(value):
+		parts := numericDatePattern.FindStringSubmatch(value)
+		day, month, year = atoi(parts[1]), time.Month(atoi(parts[2])), p.year(parts[3])
+	case namedDatePattern.MatchString(value):
+		parts := namedDatePattern.FindStringSubmatch(value)
+		named, ok := monthsByName[strings.ToLower(parts[2])]
+		if !ok {
+			return nil, &DateError{Value: value, Reason: fmt.Sprintf("unknown month %q", parts[2])}
+		}
+		day, month, year = atoi(parts[1]), named, p.year(parts[3])
+	case namedMonthPattern.MatchString(value):
+		parts := namedMonthPattern.FindStringSubmatch(value)
+		named, ok := monthsByName[strings.ToLower(parts[1])]
+		if !ok {
+			return nil, &DateError{Value: value, Reason: fmt.Sprintf("unknown month %q", parts[1])}
+		}
+		day, month, year = 1, named, p.year(parts[2])
+	default:
+		return nil, &DateError{Value: value, Reason: "unrecognised format"}
+	}
+
+	date, err := models.NewDate(year, month, day)
+	if err != nil {
+		return nil, &DateError{Value: value, Reason: "no such day"}
+	}
+
+	return &date, nil
+}
+
+// year expands two digit years around the century pivot.
+func (p DateParser) year(digits string) int {
+	year := atoi(digits)
+	if len(digits) != 2 {
+		return year
+	}
+
+	pivot := p.CenturyPivot
+	if pivot == 0 {
+		pivot = DefaultCenturyPivot
+	}
+
+	if year <= pivot {
+		return 2000 + year
+	}
+	return 1900 + year
+}
+
+// atoi is only called on strings the date patterns matched as digits.
+func atoi(digits string) int {
+	n, _ := strconv.Atoi(digits)
+	return n
+}
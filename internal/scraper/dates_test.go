@@ -0,0 +1,109 @@
+package scraper
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDateParserFormats(t *testing.T) {
+	tests := map[string]string{
+		"2024-10-17":      "2024-10-17",
+		"2024/10/7":       "2024-10-07",
+		"17/10/2024":      "2024-10-17",
+		"17-10-2024":      "2024-10-17",
+		"17.10.24":        "2024-10-17",
+		"1/2/24":          "2024-02-01",
+		"17 Oct 2024":     "2024-10-17",
+		"17 october 2024": "2024-10-17",
+		"17-Sept-24":      "2024-09-17",
+		"Oct24":           "2024-10-01",
+		"Oct 2024":        "2024-10-01",
+		"OCTOBER 2024":    "2024-10-01",
+		" 29/02/2024 ":    "2024-02-29",
+	}
+
+	for value, want := range tests {
+		date, err := DefaultDateParser.Parse(value)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", value, err)
+			continue
+		}
+		if date == nil || date.String() != want {
+			t.Errorf("Parse(%q) = %v, want %s", value, date, want)
+		}
+	}
+}
+
+func TestDateParserMissingDates(t *testing.T) {
+	for _, value := range []string{"", "n/a", "N/A", "-", "  "} {
+		date, err := DefaultDateParser.Parse(value)
+		if err != nil || date != nil {
+			t.Errorf("Parse(%q) = %v, %v, want nil, nil", value, date, err)
+		}
+	}
+}
+
+func TestDateParserRejectsInvalidDates(t *testing.T) {
+	for _, value := range []string{
+		"31/04/2024",
+		"29/02/2023",
+		"13/13/2024",
+		"32 Oct 2024",
+		"17 Octember 2024",
+		"Totals",
+		"2024-10",
+		"10/17/2024",
+	} {
+		date, err := DefaultDateParser.Parse(value)
+		var dateErr *DateError
+		if !errors.As(err, &dateErr) {
+			t.Errorf("Parse(%q) = %v, %v, want a *DateError", value, date, err)
+		}
+	}
+}
+
+func TestDateParserCenturyPivot(t *testing.T) {
+	tests := []struct {
+		pivot int
+		value string
+		want  string
+	}{
+		{pivot: 50, value: "01/01/50", want: "2050-01-01"},
+		{pivot: 50, value: "01/01/51", want: "1951-01-01"},
+		{pivot: 30, value: "01/01/45", want: "1945-01-01"},
+		{pivot: 30, value: "Mar 99", want: "1999-03-01"},
+		{pivot: 30, value: "01/01/1945", want: "1945-01-01"},
+		{pivot: 99, value: "01/01/99", want: "2099-01-01"},
+		// The zero DateParser uses DefaultCenturyPivot.
+		{pivot: 0, value: "17/10/24", want: "2024-10-17"},
+		{pivot: 0, value: "01/01/51", want: "1951-01-01"},
+	}
+
+	for _, test := range tests {
+		date, err := DateParser{CenturyPivot: test.pivot}.Parse(test.value)
+		if err != nil {
+			t.Errorf("pivot %d: Parse(%q): %v", test.pivot, test.value, err)
+			continue
+		}
+		if date.String() != test.want {
+			t.Errorf("pivot %d: Parse(%q) = %s, want %s", test.pivot, test.value, date, test.want)
+		}
+	}
+}
+
+func TestNewDateParserRejectsPivotOutOfRange(t *testing.T) {
+	// 0 would silently mean DefaultCenturyPivot, so only 1 to 99 are taken.
+	for _, pivot := range []int{0, -1, 100} {
+		if _, err := NewDateParser(pivot); err == nil {
+			t.Errorf("NewDateParser(%d) succeeded, want an error", pivot)
+		}
+	}
+
+	parser, err := NewDateParser(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if date, err := parser.Parse("01/01/02"); err != nil || date.String() != "1902-01-01" {
+		t.Errorf("pivot 1: Parse(01/01/02) = %v, %v, want 1902-01-01", date, err)
+	}
+}
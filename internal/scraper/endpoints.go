@@ -0,0 +1,115 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// FormFields names the form fields posted to and parsed from
+// HistPriceLookUp.aspx.
+type FormFields struct {
+	ManagerID string `json:"manager_id"`
+	TrustNo   string `json:"trust_no"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+// Endpoints describes where the ASISA pages live and what their forms are
+// called, so mirrors, the fake site or a moved page need no code change.
+type Endpoints struct {
+	BaseURL             string     `json:"base_url"`
+	HistPriceLookUpPath string     `json:"hist_price_lookup_path"`
+	LatestPricesPath    string     `json:"latest_prices_path"`
+	Fields              FormFields `json:"fields"`
+}
+
+var DefaultFormFields = FormFields{
+	ManagerID: "MANCO_ID",
+	TrustNo:   "TrustNo",
+	StartDate: "StartDate",
+	EndDate:   "EndDate",
+}
+
+func DefaultEndpoints() *Endpoints {
+	return &Endpoints{
+		BaseURL:             "https://funds.profiledata.co.za/aci/ASISA",
+		HistPriceLookUpPath: "/HistPriceLookUp.aspx",
+		LatestPricesPath:    "/LatestPrices.aspx",
+		Fields:              DefaultFormFields,
+	}
+}
+
+// endpointEnv maps environment variables onto the Endpoints fields they override.
+var endpointEnv = []struct {
+	name  string
+	field func(e *Endpoints) *string
+}{
+	{"ASISA_BASE_URL", func(e *Endpoints) *string { return &e.BaseURL }},
+	{"ASISA_HIST_PRICE_LOOKUP_PATH", func(e *Endpoints) *string { return &e.HistPriceLookUpPath }},
+	{"ASISA_LATEST_PRICES_PATH", func(e *Endpoints) *string { return &e.LatestPricesPath }},
+	{"ASISA_FIELD_MANAGER_ID", func(e *Endpoints) *string { return &e.Fields.ManagerID }},
+	{"ASISA_FIELD_TRUST_NO", func(e *Endpoints) *string { return &e.Fields.TrustNo }},
+	{"ASISA_FIELD_START_DATE", func(e *Endpoints) *string { return &e.Fields.StartDate }},
+	{"ASISA_FIELD_END_DATE", func(e *Endpoints) *string { return &e.Fields.EndDate }},
+}
+
+// LoadEndpoints starts from DefaultEndpoints, applies the JSON file at path
+// when path is not empty and then any ASISA_* environment variables. Fields
+// missing from the file keep their defaults.
+func LoadEndpoints(path string) (*Endpoints, error) {
+	endpoints := DefaultEndpoints()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading endpoints config: %w", err)
+		}
+
+		if err := json.Unmarshal(data, endpoints); err != nil {
+			return nil, fmt.Errorf("error parsing endpoints config %s: %w", path, err)
+		}
+	}
+
+	for _, env := range endpointEnv {
+		if value := os.Getenv(env.name); value != "" {
+			*env.field(endpoints) = value
+		}
+	}
+
+	if err := endpoints.Validate(); err != nil {
+		return nil, err
+	}
+
+	return endpoints, nil
+}
+
+// Validate checks that the base URL is absolute and no name is empty.
+func (e *Endpoints) Validate() error {
+	base, err := url.Parse(e.BaseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return fmt.Errorf("invalid endpoints base url %q", e.BaseURL)
+	}
+
+	for _, env := range endpointEnv {
+		if *env.field(e) == "" {
+			return fmt.Errorf("invalid endpoints config: %s is empty", env.name)
+		}
+	}
+
+	return nil
+}
+
+func (e *Endpoints) HistPriceLookUpURL() string {
+	return e.join(e.HistPriceLookUpPath)
+}
+
+func (e *Endpoints) LatestPricesURL() string {
+	return e.join(e.LatestPricesPath)
+}
+
+func (e *Endpoints) join(path string) string {
+	return strings.TrimSuffix(e.BaseURL, "/") + "/" + strings.TrimPrefix(path, "/")
+}
@@ -0,0 +1,55 @@
+package scraper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadEndpoints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "endpoints.json")
+	config := `{"base_url": "https://mirror.example.com/asisa/", "latest_prices_path": "Prices/Latest.aspx", "fields": {"manager_id": "ctl00$ManCo"}}`
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("ASISA_FIELD_TRUST_NO", "ctl00$Fund")
+
+	endpoints, err := LoadEndpoints(path)
+	if err != nil {
+		t.Fatalf("LoadEndpoints: %v", err)
+	}
+
+	if got, want := endpoints.LatestPricesURL(), "https://mirror.example.com/asisa/Prices/Latest.aspx"; got != want {
+		t.Errorf("LatestPricesURL() = %q, want %q", got, want)
+	}
+	if got, want := endpoints.HistPriceLookUpURL(), "https://mirror.example.com/asisa/HistPriceLookUp.aspx"; got != want {
+		t.Errorf("HistPriceLookUpURL() = %q, want %q", got, want)
+	}
+
+	want := FormFields{ManagerID: "ctl00$ManCo", TrustNo: "ctl00$Fund", StartDate: "StartDate", EndDate: "EndDate"}
+	if endpoints.Fields != want {
+		t.Errorf("Fields = %+v, want %+v", endpoints.Fields, want)
+	}
+}
+
+func TestLoadEndpointsRejectsInvalidConfig(t *testing.T) {
+	tests := map[string]string{
+		"relative base url": `{"base_url": "/asisa"}`,
+		"empty field":       `{"fields": {"trust_no": ""}}`,
+		"malformed json":    `{"base_url": `,
+	}
+
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "endpoints.json")
+			if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := LoadEndpoints(path); err == nil {
+				t.Errorf("LoadEndpoints(%s) succeeded, want an error", config)
+			}
+		})
+	}
+}
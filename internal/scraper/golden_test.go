@@ -0,0 +1,121 @@
+package scraper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenOutput is what gets written to a .golden.json file: the parsed result,
+// or the error for fixtures that are meant to be rejected.
+type goldenOutput struct {
+	Result any          `json:"result,omitempty"`
+	Error  string       `json:"error,omitempty"`
+	Layout *LayoutError `json:"layout,omitempty"`
+}
+
+// parsedOutput is a parser's results together with its diagnostics.
+type parsedOutput struct {
+	Rows        any
+	Diagnostics Diagnostics
+}
+
+func withDiagnostics[T any](rows T, diagnostics Diagnostics, err error) (any, error) {
+	return parsedOutput{Rows: rows, Diagnostics: diagnostics}, err
+}
+
+func TestParsersGolden(t *testing.T) {
+	latestPrices := func(html []byte) (any, error) {
+		return withDiagnostics(ScrapeCurrentPriceAndCostData(html, DefaultDateParser))
+	}
+
+	tests := []struct {
+		fixture string
+		parse   func(html []byte) (any, error)
+	}{
+		{"cis_managers.html", func(html []byte) (any, error) { return withDiagnostics(ScrapeCISMangers(html, DefaultFormFields)) }},
+		{"funds.html", func(html []byte) (any, error) { return withDiagnostics(ScrapeFunds(html, DefaultFormFields, 303)) }},
+		{"latest_prices.html", latestPrices},
+		{"latest_prices_extra_column.html", latestPrices},
+		{"latest_prices_missing_column.html", latestPrices},
+		{"latest_prices_reordered.html", latestPrices},
+		{"latest_prices_td_header.html", latestPrices},
+		{"historical_prices.html", func(html []byte) (any, error) {
+			return withDiagnostics(ScrapeHistoricalPrices(html, DefaultDateParser))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			html := readFixture(t, tt.fixture)
+
+			result, err := tt.parse(html)
+			output := goldenOutput{Result: result}
+			if err != nil {
+				output = goldenOutput{Error: err.Error()}
+				errors.As(err, &output.Layout)
+			}
+
+			assertGolden(t, tt.fixture, output)
+		})
+	}
+}
+
+func TestExtractViewStateDataGolden(t *testing.T) {
+	for _, fixture := range []string{"cis_managers.html", "funds.html", "latest_prices.html"} {
+		t.Run(fixture, func(t *testing.T) {
+			viewState, err := ExtractViewStateData(readFixture(t, fixture))
+			if err != nil {
+				t.Fatalf("ExtractViewStateData: %v", err)
+			}
+
+			assertGolden(t, "viewstate_"+fixture, goldenOutput{Result: viewState})
+		})
+	}
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	html, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("error reading fixture: %v", err)
+	}
+
+	return html
+}
+
+// assertGolden compares got, as indented JSON, with testdata/<fixture>.golden.json.
+// Run the tests with -update to accept new output.
+func assertGolden(t *testing.T, fixture string, got any) {
+	t.Helper()
+
+	actual, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatalf("error marshalling output: %v", err)
+	}
+	actual = append(actual, '\n')
+
+	name := filepath.Join("testdata", fixture+".golden.json")
+	if *update {
+		if err := os.WriteFile(name, actual, 0o644); err != nil {
+			t.Fatalf("error writing golden file: %v", err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("error reading golden file, run with -update to create it: %v", err)
+	}
+
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("output does not match %s, run with -update to accept it\ngot:\n%s\nwant:\n%s", name, actual, expected)
+	}
+}
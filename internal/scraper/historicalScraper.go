@@ -3,7 +3,6 @@ package scraper
 import (
 	"bytes"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
@@ -14,18 +13,39 @@ type FundPricingData struct {
 	FundClass *models.FundClass
 	Costs     *models.FundClassCost
 	Price     *models.FundClassPrice
+	// ManagerName is the management company block the row was listed under,
+	// read from the preceding tr.mancorow, and empty when the page did not
+	// give one.
+	ManagerName string
 }
 
-func ScrapeCurrentPriceAndCostData(html []byte) ([]*FundPricingData, error) {
+// ScrapeCurrentPriceAndCostData reads the LatestPrices table. Fund rows
+// without a name or with fewer cells than the header are dropped, cells that
+// cannot be read are left empty, and both are reported in the diagnostics. A
+// header that lacks or reorders columns is a LayoutError.
+func ScrapeCurrentPriceAndCostData(html []byte, dates DateParser) ([]*FundPricingData, Diagnostics, error) {
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
 	if err != nil {
-		return nil, fmt.Errorf("error reading in html document: %s", err)
+		return nil, Diagnostics{}, fmt.Errorf("error reading in html document: %s", err)
+	}
+
+	table := doc.Find("#dataTable")
+	layout, err := findColumnLayout(table)
+	if err != nil {
+		return nil, Diagnostics{}, err
 	}
 
 	var results []*FundPricingData
+	var diagnostics Diagnostics
 	currentCategory := ""
+	currentManager := ""
+
+	table.Find("tr").Each(func(i int, s *goquery.Selection) {
+		if s.HasClass("mancorow") {
+			currentManager = strings.TrimSpace(s.Find("td").First().Text())
+			return
+		}
 
-	doc.Find("#dataTable tr").Each(func(i int, s *goquery.Selection) {
 		if s.HasClass("sectorrow") {
 			categoryText := s.Find("td").First().Text()
 			currentCategory = strings.TrimSpace(categoryText)
@@ -36,43 +56,72 @@ func ScrapeCurrentPriceAndCostData(html []byte) ([]*FundPricingData, error) {
 			return
 		}
 
+		// Suspended classes are sometimes printed as a name followed by one
+		// spanning cell, which leaves the row without prices but says
+		// nothing about the columns of the other rows.
 		tds := s.Find("td")
-		if tds.Length() < 11 {
+		if tds.Length() < layout.width {
+			missing := columnNames[layout.firstColumnFrom(tds.Length())]
+			diagnostics.drop(RowWarning{
+				Row:     i,
+				Field:   missing,
+				Value:   strings.Join(strings.Fields(s.Text()), " "),
+				Message: fmt.Sprintf("row has %d of %d cells", tds.Length(), layout.width),
+				Item:    strings.TrimSpace(s.Find("div.fundname").Text()),
+			})
 			return
 		}
 
-		fundNameFull := strings.TrimSpace(tds.Eq(0).Find("div.fundname").Text())
+		nameCell := tds.Eq(layout.indexes[colFundName])
+		fundNameFull := strings.TrimSpace(nameCell.Find("div.fundname").Text())
 		if fundNameFull == "" {
+			diagnostics.drop(RowWarning{Row: i, Field: columnNames[colFundName], Value: strings.TrimSpace(nameCell.Text()), Message: "row has no fund name"})
 			return
 		}
 
 		fundName, className := parseFundNameAndClass(fundNameFull)
 
-		targetMarket := strings.TrimSpace(tds.Eq(2).Text())
+		targetMarket := layout.cell(tds, colTargetMarket)
 
-		addFee := strings.TrimSpace(tds.Eq(1).Text()) == "yes"
+		addFee := layout.cell(tds, colAddFee) == "yes"
 
-		maxInitFeeStr := strings.TrimSpace(tds.Eq(3).Text())
-		maxInitFee := parsePercentage(maxInitFeeStr)
+		warn := func(col int, value string, err error) {
+			diagnostics.warn(RowWarning{Row: i, Field: columnNames[col], Value: value, Message: err.Error(), Item: fundNameFull})
+		}
+
+		parseDate := func(col int) *models.Date {
+			value := layout.cell(tds, col)
+			date, err := dates.Parse(value)
+			if err != nil {
+				warn(col, value, err)
+			}
+			return date
+		}
 
-		ticDate := parseDate(strings.TrimSpace(tds.Eq(4).Text()))
+		parseNumber := func(col int, parse func(string) (*models.Decimal, error)) *models.Decimal {
+			value := layout.cell(tds, col)
+			number, err := parse(value)
+			if err != nil {
+				warn(col, value, err)
+			}
+			return number
+		}
 
-		terPerfCompStr := strings.TrimSpace(tds.Eq(5).Text())
-		terPerfComp := parsePercentage(terPerfCompStr)
+		maxInitFee := parseNumber(colMaxInitFee, parsePercentage)
 
-		terStr := strings.TrimSpace(tds.Eq(6).Text())
-		ter := parsePercentage(terStr)
+		ticDate := parseDate(colTICDate)
 
-		tcStr := strings.TrimSpace(tds.Eq(7).Text())
-		tc := parsePercentage(tcStr)
+		terPerfComp := parseNumber(colTERPerfComp, parsePercentage)
 
-		ticStr := strings.TrimSpace(tds.Eq(8).Text())
-		tic := parsePercentage(ticStr)
+		ter := parseNumber(colTER, parsePercentage)
 
-		priceDate := parseDate(strings.TrimSpace(tds.Eq(9).Text()))
+		tc := parseNumber(colTC, parsePercentage)
 
-		navStr := strings.TrimSpace(tds.Eq(10).Text())
-		nav := parseDecimal(navStr)
+		tic := parseNumber(colTIC, parsePercentage)
+
+		priceDate := parseDate(colPriceDate)
+
+		nav := parseNumber(colNAV, parseDecimal)
 
 		data := &FundPricingData{
 			FundClass: &models.FundClass{
@@ -94,11 +143,14 @@ func ScrapeCurrentPriceAndCostData(html []byte) ([]*FundPricingData, error) {
 				PriceDate: priceDate,
 				NAV:       nav,
 			},
+			ManagerName: currentManager,
 		}
 
 		results = append(results, data)
+		diagnostics.Parsed++
 	})
-	return results, nil
+
+	return results, diagnostics, nil
 }
 
 func parseFundNameAndClass(fundNameFull string) (string, string) {
@@ -117,85 +169,117 @@ func parseFundNameAndClass(fundNameFull string) (string, string) {
 	return fundNameFull, ""
 }
 
-func parsePercentage(percentage string) *float64 {
-	percentage = strings.TrimSpace(percentage)
-	if percentage == "n/a" || percentage == "" {
-		return nil
-	}
-
-	percentage = strings.TrimSuffix(percentage, "%")
-	val, err := strconv.ParseFloat(percentage, 64)
-
-	if err != nil {
-		return nil
-	}
-
-	return &val
+// parsePercentage returns nil and no error for "n/a" and blank cells.
+func parsePercentage(percentage string) (*models.Decimal, error) {
+	return parseDecimal(strings.TrimSuffix(strings.TrimSpace(percentage), "%"))
 }
 
-func parseDecimal(decimal string) *float64 {
+// parseDecimal returns nil and no error for "n/a" and blank cells.
+func parseDecimal(decimal string) (*models.Decimal, error) {
 	decimal = strings.TrimSpace(decimal)
 
 	if decimal == "n/a" || decimal == "" {
-		return nil
+		return nil, nil
 	}
 
-	val, err := strconv.ParseFloat(decimal, 64)
+	val, err := models.ParseDecimal(decimal)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
-	return &val
+	return &val, nil
+}
+
+type HistoricalPrice struct {
+	ClassName string
+	Price     *models.FundClassPrice
 }
 
-func parseDate(date string) *string {
-	date = strings.TrimSpace(date)
-	if date == "n/a" || date == "" {
-		return nil
+// ScrapeHistoricalPrices reads the Date by class price table. Rows without a
+// readable date are dropped, NAV cells that cannot be read are skipped, and
+// both are reported in the diagnostics.
+func ScrapeHistoricalPrices(html []byte, dates DateParser) ([]*HistoricalPrice, Diagnostics, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+
+	if err != nil {
+		return nil, Diagnostics{}, fmt.Errorf("failed to read document from html: %s", err)
 	}
 
-	if strings.Contains(date, "/") {
-		parts := strings.Split(date, "/")
-		if len(parts) == 3 {
-			day := parts[0]
-			month := parts[1]
-			year := parts[2]
+	var prices []*HistoricalPrice
+	var diagnostics Diagnostics
+	doc.Find("table").EachWithBreak(func(i int, table *goquery.Selection) bool {
+		header := table.Find("tr").First().Find("th, td")
+		if header.Length() < 2 || !strings.EqualFold(strings.TrimSpace(header.First().Text()), "Date") {
+			return true
+		}
 
-			if len(year) == 2 {
-				yearInt, _ := strconv.Atoi(year)
+		var classNames []string
+		header.Each(func(j int, cell *goquery.Selection) {
+			if j > 0 {
+				classNames = append(classNames, normalizeClassName(cell.Text()))
+			}
+		})
 
-				if yearInt >= 0 && yearInt <= 50 {
-					year = "20" + year
-				} else {
-					year = "19" + year
+		table.Find("tr").Slice(1, goquery.ToEnd).Each(func(j int, row *goquery.Selection) {
+			tds := row.Find("td")
+			if tds.Length() < 2 {
+				if text := strings.TrimSpace(row.Text()); text != "" {
+					diagnostics.drop(RowWarning{Row: j + 1, Value: text, Message: fmt.Sprintf("expected a date and prices, found %d cells", tds.Length())})
 				}
+				return
 			}
 
-			result := fmt.Sprintf("%s-%s-%s", year, month, day)
-			return &result
-		}
-	}
+			value := strings.TrimSpace(tds.First().Text())
+			priceDate, err := dates.Parse(value)
+			if err != nil {
+				diagnostics.drop(RowWarning{Row: j + 1, Field: "Date", Value: value, Message: err.Error()})
+				return
+			}
+			if priceDate == nil {
+				diagnostics.drop(RowWarning{Row: j + 1, Field: "Date", Value: value, Message: "row has no date"})
+				return
+			}
 
-	if len(date) == 5 {
-		monthMap := map[string]string{
-			"Jan": "01", "Feb": "02", "Mar": "03", "Apr": "04",
-			"May": "05", "Jun": "06", "Jul": "07", "Aug": "08",
-			"Sep": "09", "Oct": "10", "Nov": "11", "Dec": "12",
-		}
+			diagnostics.Parsed++
+			tds.Slice(1, goquery.ToEnd).Each(func(k int, cell *goquery.Selection) {
+				if k >= len(classNames) {
+					return
+				}
 
-		monthStr := date[:3]
-		yearStr := date[3:]
+				text := strings.TrimSpace(cell.Text())
+				nav, err := parseDecimal(text)
+				if err != nil {
+					diagnostics.warn(RowWarning{Row: j + 1, Field: classNames[k], Value: text, Message: err.Error()})
+					return
+				}
+				if nav == nil {
+					return
+				}
 
-		if month, ok := monthMap[monthStr]; ok {
-			yearInt, _ := strconv.Atoi(yearStr)
-			if yearInt >= 0 && yearInt <= 50 {
-				yearStr = "20" + yearStr
-			} else {
-				yearStr = "19" + yearStr
-			}
-			result := fmt.Sprintf("%s-%s-01", yearStr, month)
-			return &result
-		}
+				prices = append(prices, &HistoricalPrice{
+					ClassName: classNames[k],
+					Price: &models.FundClassPrice{
+						PriceDate: priceDate,
+						NAV:       nav,
+					},
+				})
+			})
+		})
+		return false
+	})
+
+	return prices, diagnostics, nil
+}
+
+func normalizeClassName(className string) string {
+	className = strings.TrimSpace(className)
+	if className == "" {
+		return ""
 	}
-	return nil
+
+	if strings.HasPrefix(strings.ToLower(className), "class ") {
+		return "Class " + strings.TrimSpace(className[len("class "):])
+	}
+
+	return "Class " + className
 }
@@ -0,0 +1,176 @@
+package scraper
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Columns of the LatestPrices table, in the order the site lists them.
+const (
+	colFundName = iota
+	colAddFee
+	colTargetMarket
+	colMaxInitFee
+	colTICDate
+	colTERPerfComp
+	colTER
+	colTC
+	colTIC
+	colPriceDate
+	colNAV
+	columnCount
+)
+
+// latestPricesColumns holds the header labels accepted for each column,
+// normalized by normalizeHeader.
+var latestPricesColumns = [columnCount][]string{
+	colFundName:     {"fund", "fund name", "fund class", "name"},
+	colAddFee:       {"add fee", "additional fee", "add fees"},
+	colTargetMarket: {"target market"},
+	colMaxInitFee:   {"max init fee", "max initial fee", "maximum initial fee"},
+	colTICDate:      {"tic date", "cost date"},
+	colTERPerfComp:  {"ter perf comp", "ter performance component", "ter pf", "perf comp"},
+	colTER:          {"ter"},
+	colTC:           {"tc", "transaction costs"},
+	colTIC:          {"tic", "total investment charges"},
+	colPriceDate:    {"price date", "date"},
+	colNAV:          {"nav", "price"},
+}
+
+var columnNames = [columnCount]string{
+	"Fund", "Add Fee", "Target Market", "Max Init Fee", "TIC Date", "TER Perf Comp", "TER", "TC", "TIC", "Price Date", "NAV",
+}
+
+// LayoutError is returned when the LatestPrices table does not look like the
+// one the parser was written for, so callers can refuse to save anything
+// rather than store values under the wrong columns.
+type LayoutError struct {
+	// Header is the header row as observed on the page.
+	Header []string
+	// Missing lists the expected columns that were not found.
+	Missing []string
+	// Reordered is set when all columns were found but not in the expected order.
+	Reordered bool
+}
+
+func (e *LayoutError) Error() string {
+	header := strings.Join(e.Header, " | ")
+
+	switch {
+	case len(e.Header) == 0:
+		return "unexpected LatestPrices layout: no header row found"
+	case len(e.Missing) > 0:
+		return fmt.Sprintf("unexpected LatestPrices layout: missing columns %s (header: %s)", strings.Join(e.Missing, ", "), header)
+	default:
+		return fmt.Sprintf("unexpected LatestPrices layout: columns reordered (header: %s)", header)
+	}
+}
+
+// columnLayout maps each expected column to its cell index in a fund row.
+type columnLayout struct {
+	header  []string
+	indexes [columnCount]int
+	width   int
+}
+
+// findColumnLayout reads the first header row of table and maps the expected
+// columns by name. Extra columns are ignored.
+func findColumnLayout(table *goquery.Selection) (*columnLayout, error) {
+	headerRow := table.Find("tr").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return isHeaderRow(s)
+	}).First()
+
+	var header []string
+	headerRow.Find("th, td").Each(func(_ int, s *goquery.Selection) {
+		header = append(header, strings.Join(strings.Fields(s.Text()), " "))
+	})
+
+	if len(header) == 0 {
+		return nil, &LayoutError{}
+	}
+
+	layout := &columnLayout{header: header}
+	var missing []string
+
+	for col, labels := range latestPricesColumns {
+		layout.indexes[col] = -1
+		for i, label := range header {
+			if slices.Contains(labels, normalizeHeader(label)) {
+				layout.indexes[col] = i
+				break
+			}
+		}
+
+		if layout.indexes[col] == -1 {
+			missing = append(missing, columnNames[col])
+			continue
+		}
+		layout.width = max(layout.width, layout.indexes[col]+1)
+	}
+
+	if len(missing) > 0 {
+		return nil, &LayoutError{Header: header, Missing: missing}
+	}
+
+	for col := 1; col < columnCount; col++ {
+		if layout.indexes[col] < layout.indexes[col-1] {
+			return nil, &LayoutError{Header: header, Reordered: true}
+		}
+	}
+
+	return layout, nil
+}
+
+// isHeaderRow reports whether row is the table header: a row of th cells, a
+// row marked headerrow, or a plain row of td cells most of which name
+// expected columns, as some renderings of the page print the header.
+func isHeaderRow(row *goquery.Selection) bool {
+	if row.Find("th").Length() > 0 || row.HasClass("headerrow") {
+		return true
+	}
+
+	known := 0
+	row.Find("td").Each(func(_ int, s *goquery.Selection) {
+		label := normalizeHeader(s.Text())
+		for _, labels := range latestPricesColumns {
+			if slices.Contains(labels, label) {
+				known++
+				break
+			}
+		}
+	})
+
+	return known > columnCount/2
+}
+
+// firstColumnFrom returns the first expected column at or after cell index i.
+func (l *columnLayout) firstColumnFrom(i int) int {
+	for col, index := range l.indexes {
+		if index >= i {
+			return col
+		}
+	}
+	return columnCount - 1
+}
+
+// cell returns the trimmed text of column col in a fund row.
+func (l *columnLayout) cell(tds *goquery.Selection, col int) string {
+	return strings.TrimSpace(tds.Eq(l.indexes[col]).Text())
+}
+
+// normalizeHeader lowercases a header label and drops punctuation such as
+// "(%)" so that "TER (%)" and "TER" compare equal.
+func normalizeHeader(label string) string {
+	label = strings.ToLower(label)
+	label = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, label)
+	return strings.Join(strings.Fields(label), " ")
+}
@@ -0,0 +1,173 @@
+package scraper
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// PolitenessPolicy controls how the client backs off between attempts.
+type PolitenessPolicy struct {
+	// BaseBackoff is the wait after the first failed attempt, doubled on each
+	// further attempt up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Jitter is the fraction (0-1) of each backoff that is randomised so
+	// concurrent workers do not retry in lockstep.
+	Jitter float64
+	// HonourRetryAfter waits for the server's Retry-After on 429 and 503
+	// responses, capped at MaxRetryAfter.
+	HonourRetryAfter bool
+	MaxRetryAfter    time.Duration
+}
+
+var defaultPoliteness = PolitenessPolicy{
+	BaseBackoff:      1 * time.Second,
+	MaxBackoff:       30 * time.Second,
+	Jitter:           0.5,
+	HonourRetryAfter: true,
+	MaxRetryAfter:    2 * time.Minute,
+}
+
+func (p PolitenessPolicy) backoff(attempt int) time.Duration {
+	backoff := float64(p.BaseBackoff) * math.Pow(2, float64(attempt))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+
+	jitter := min(max(p.Jitter, 0), 1)
+	return time.Duration(backoff*(1-jitter) + backoff*jitter*rand.Float64())
+}
+
+// retryAfter returns the wait requested by a 429 or 503 response, if any,
+// with an HTTP date measured from now.
+func (p PolitenessPolicy) retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if !p.HonourRetryAfter {
+		return 0, false
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	header := resp.Header.Get("Retry-After")
+	if header == "" {
+		return 0, false
+	}
+
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(header); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(header); err == nil {
+		wait = at.Sub(now)
+	} else {
+		return 0, false
+	}
+
+	wait = max(wait, 0)
+	if p.MaxRetryAfter > 0 && wait > p.MaxRetryAfter {
+		wait = p.MaxRetryAfter
+	}
+
+	return wait, true
+}
+
+// isRetryableStatus reports whether a status is worth sending the same
+// request again for. A 500 is not: ASP.NET answers a stale or rejected
+// post-back, such as a viewstate MAC failure, with one, and resending it
+// unchanged fails the same way.
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// tokenBucket allows rate requests per second on average with bursts of up
+// to burst requests.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(max(burst, 1)),
+		tokens: float64(max(burst, 1)),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait before
+// using it.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// hostLimiter keeps one token bucket per host.
+type hostLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   int
+	buckets map[string]*tokenBucket
+}
+
+func newHostLimiter(rate float64, burst int) *hostLimiter {
+	return &hostLimiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	l.mu.Lock()
+	bucket, exists := l.buckets[host]
+	if !exists {
+		bucket = newTokenBucket(l.rate, l.burst)
+		l.buckets[host] = bucket
+	}
+	l.mu.Unlock()
+
+	return sleepContext(ctx, bucket.reserve())
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
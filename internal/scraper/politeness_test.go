@@ -0,0 +1,165 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketBurstAndRefill(t *testing.T) {
+	now := time.Date(2024, 10, 17, 8, 0, 0, 0, time.UTC)
+	bucket := newTokenBucket(2, 3)
+	bucket.last = now
+	bucket.now = func() time.Time { return now }
+
+	// The full burst goes out at once, the next request waits for a token.
+	for i := 0; i < 3; i++ {
+		if wait := bucket.reserve(); wait != 0 {
+			t.Fatalf("request %d of the burst waits %s", i+1, wait)
+		}
+	}
+	if wait := bucket.reserve(); wait != 500*time.Millisecond {
+		t.Errorf("request after the burst waits %s, want 500ms at 2 per second", wait)
+	}
+
+	// A second refills two tokens, one of them owed to the request above.
+	now = now.Add(time.Second)
+	if wait := bucket.reserve(); wait != 0 {
+		t.Errorf("after a second: wait %s, want none", wait)
+	}
+	if wait := bucket.reserve(); wait != 500*time.Millisecond {
+		t.Errorf("after a second, second request waits %s, want 500ms", wait)
+	}
+
+	// An idle bucket refills to the burst and no further.
+	now = now.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		if wait := bucket.reserve(); wait != 0 {
+			t.Fatalf("after a minute, request %d waits %s", i+1, wait)
+		}
+	}
+	if wait := bucket.reserve(); wait == 0 {
+		t.Error("after a minute, the bucket held more than its burst")
+	}
+}
+
+func TestBackoffCapAndJitter(t *testing.T) {
+	policy := PolitenessPolicy{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second, Jitter: 0.5}
+
+	tests := []struct {
+		attempt int
+		full    time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{3, 8 * time.Second},
+		{4, 10 * time.Second},
+		{20, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			wait := policy.backoff(tt.attempt)
+			if wait < tt.full/2 || wait > tt.full {
+				t.Fatalf("attempt %d waits %s, want between %s and %s", tt.attempt, wait, tt.full/2, tt.full)
+			}
+		}
+	}
+
+	steady := PolitenessPolicy{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second}
+	if wait := steady.backoff(2); wait != 4*time.Second {
+		t.Errorf("without jitter attempt 2 waits %s, want 4s", wait)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 10, 17, 8, 0, 0, 0, time.UTC)
+	policy := PolitenessPolicy{HonourRetryAfter: true, MaxRetryAfter: time.Minute}
+
+	tests := []struct {
+		name   string
+		status int
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"seconds", http.StatusTooManyRequests, "5", 5 * time.Second, true},
+		{"seconds past the cap", http.StatusServiceUnavailable, "600", time.Minute, true},
+		{"date", http.StatusTooManyRequests, now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second, true},
+		{"date past the cap", http.StatusTooManyRequests, now.Add(time.Hour).Format(http.TimeFormat), time.Minute, true},
+		{"date in the past", http.StatusTooManyRequests, now.Add(-time.Hour).Format(http.TimeFormat), 0, true},
+		{"missing", http.StatusTooManyRequests, "", 0, false},
+		{"unreadable", http.StatusTooManyRequests, "soon", 0, false},
+		{"other status", http.StatusBadGateway, "5", 0, false},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+		if tt.header != "" {
+			resp.Header.Set("Retry-After", tt.header)
+		}
+
+		wait, ok := policy.retryAfter(resp, now)
+		if wait != tt.want || ok != tt.ok {
+			t.Errorf("%s: retryAfter = %s, %v, want %s, %v", tt.name, wait, ok, tt.want, tt.ok)
+		}
+	}
+
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"5"}}}
+	if _, ok := (PolitenessPolicy{}).retryAfter(resp, now); ok {
+		t.Error("Retry-After honoured with HonourRetryAfter off")
+	}
+}
+
+func TestClientWaitsForRetryAfter(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// Retry-After asks for a second, which the cap shortens.
+	client := NewClient(WithPoliteness(PolitenessPolicy{
+		BaseBackoff:      time.Hour,
+		HonourRetryAfter: true,
+		MaxRetryAfter:    50 * time.Millisecond,
+	}))
+
+	start := time.Now()
+	body, err := client.GetContext(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != "ok" || requests.Load() != 2 {
+		t.Errorf("body %q after %d requests, want ok after 2", body, requests.Load())
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 5*time.Second {
+		t.Errorf("retried after %s, want the capped 50ms Retry-After", elapsed)
+	}
+}
+
+func TestClientDoesNotResendFailedPostBack(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "Validation of viewstate MAC failed", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithPoliteness(PolitenessPolicy{BaseBackoff: time.Millisecond}))
+	if _, err := client.PostContext(context.Background(), server.URL, nil); err == nil {
+		t.Fatal("a 500 post-back succeeded")
+	}
+	if requests.Load() != 1 {
+		t.Errorf("sent %d requests, want the failed post-back sent once", requests.Load())
+	}
+}
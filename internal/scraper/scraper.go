@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Alexandervanderleek/FundFinderZA/internal/models"
 	"github.com/PuerkitoBio/goquery"
@@ -34,64 +36,101 @@ func ExtractViewStateData(html []byte) (*ViewStateData, error) {
 	}, nil
 }
 
-func BuildFormData(viewStateDate *ViewStateData, mancoId int) url.Values {
+func BuildFormData(viewStateDate *ViewStateData, fields FormFields, mancoId int) url.Values {
 	formData := url.Values{}
 	formData.Set("__VIEWSTATE", viewStateDate.ViewState)
 	formData.Set("__VIEWSTATEGENERATOR", viewStateDate.ViewStateGenerator)
 	formData.Set("__EVENTVALIDATION", viewStateDate.EventValidation)
-	formData.Set("MANCO_ID", fmt.Sprintf("%04d", mancoId))
+	formData.Set(fields.ManagerID, fmt.Sprintf("%04d", mancoId))
 	return formData
 }
 
-func ScrapeCISMangers(html []byte) ([]*models.CISManager, error) {
+func BuildPriceFormData(viewStateDate *ViewStateData, fields FormFields, mancoId int, trustNo int, from time.Time, to time.Time) url.Values {
+	formData := BuildFormData(viewStateDate, fields, mancoId)
+	formData.Set(fields.TrustNo, strconv.Itoa(trustNo))
+	formData.Set(fields.StartDate, from.Format("02/01/2006"))
+	formData.Set(fields.EndDate, to.Format("02/01/2006"))
+	return formData
+}
+
+// ScrapeCISMangers reads the management company drop down. The blank
+// "Select" option is skipped, other options without a numeric id or a name
+// are dropped and reported.
+func ScrapeCISMangers(html []byte, fields FormFields) ([]*models.CISManager, Diagnostics, error) {
 
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
 
 	if err != nil {
-		return nil, err
+		return nil, Diagnostics{}, err
 	}
 
 	var managers []*models.CISManager
-	doc.Find("select[name='MANCO_ID'] option").Each(func(i int, s *goquery.Selection) {
-		value, exists := s.Attr("value")
-
-		integerVal, err := strconv.Atoi(value)
-
-		if exists && err == nil && s.Text() != "" {
-			manager := &models.CISManager{
-				ID:   integerVal,
-				Name: s.Text(),
-			}
-			managers = append(managers, manager)
+	var diagnostics Diagnostics
+	doc.Find(fmt.Sprintf("select[name='%s'] option", fields.ManagerID)).Each(func(i int, s *goquery.Selection) {
+		id, name, ok := parseOption(s, i, "Manager ID", &diagnostics)
+		if !ok {
+			return
 		}
+
+		managers = append(managers, &models.CISManager{
+			ID:   id,
+			Name: name,
+		})
+		diagnostics.Parsed++
 	})
-	return managers, nil
+	return managers, diagnostics, nil
 }
 
-func ScrapeFunds(html []byte, managerId int) ([]*models.Fund, error) {
+// ScrapeFunds reads the fund drop down shown once a manager is selected,
+// dropping and reporting options the same way as ScrapeCISMangers.
+func ScrapeFunds(html []byte, fields FormFields, managerId int) ([]*models.Fund, Diagnostics, error) {
 
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to read document from html: %s", err)
+		return nil, Diagnostics{}, fmt.Errorf("failed to read document from html: %s", err)
 	}
 
 	var funds []*models.Fund
-	doc.Find("select[name='TrustNo'] option").Each(func(i int, s *goquery.Selection) {
-		value, exists := s.Attr("value")
-
-		intVal, err := strconv.Atoi(value)
-
-		if exists && err == nil && s.Text() != "" {
-			fund := &models.Fund{
-				TrustNo:       intVal,
-				Name:          s.Text(),
-				SecondaryName: "",
-				ManagerID:     managerId,
-			}
-			funds = append(funds, fund)
+	var diagnostics Diagnostics
+	doc.Find(fmt.Sprintf("select[name='%s'] option", fields.TrustNo)).Each(func(i int, s *goquery.Selection) {
+		trustNo, name, ok := parseOption(s, i, "Trust No", &diagnostics)
+		if !ok {
+			return
 		}
+
+		funds = append(funds, &models.Fund{
+			TrustNo:       trustNo,
+			Name:          name,
+			SecondaryName: "",
+			ManagerID:     managerId,
+		})
+		diagnostics.Parsed++
 	})
 
-	return funds, nil
+	return funds, diagnostics, nil
+}
+
+// parseOption reads the numeric value and the text of a drop down option.
+// Options without a value are the "Select" placeholder and are skipped
+// without a warning.
+func parseOption(s *goquery.Selection, row int, field string, diagnostics *Diagnostics) (int, string, bool) {
+	value, _ := s.Attr("value")
+	name := s.Text()
+	if strings.TrimSpace(value) == "" {
+		return 0, "", false
+	}
+
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		diagnostics.drop(RowWarning{Row: row, Field: field, Value: value, Message: fmt.Sprintf("%q is not a number", value), Item: name})
+		return 0, "", false
+	}
+
+	if name == "" {
+		diagnostics.drop(RowWarning{Row: row, Field: "Name", Value: value, Message: "option has no name"})
+		return 0, "", false
+	}
+
+	return id, name, true
 }
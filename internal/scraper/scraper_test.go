@@ -0,0 +1,63 @@
+package scraper
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBuildPriceFormData(t *testing.T) {
+	viewState := &ViewStateData{ViewState: "state", ViewStateGenerator: "gen", EventValidation: "valid"}
+	from := time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, time.October, 17, 0, 0, 0, 0, time.UTC)
+
+	form := BuildPriceFormData(viewState, DefaultFormFields, 37, 1261, from, to)
+
+	want := map[string]string{
+		"__VIEWSTATE":          "state",
+		"__VIEWSTATEGENERATOR": "gen",
+		"__EVENTVALIDATION":    "valid",
+		"MANCO_ID":             "0037",
+		"TrustNo":              "1261",
+		"StartDate":            "02/01/2020",
+		"EndDate":              "17/10/2024",
+	}
+	for field, value := range want {
+		if got := form.Get(field); got != value {
+			t.Errorf("%s = %q, want %q", field, got, value)
+		}
+	}
+	if len(form) != len(want) {
+		t.Errorf("form has %d fields, want %d: %v", len(form), len(want), form)
+	}
+}
+
+func TestScrapeHistoricalPrices(t *testing.T) {
+	html := []byte(`<html><body>
+<table><tr><td>Start Date</td><td>01/10/2024</td></tr></table>
+<table>
+	<tr><th>Date</th><th>A</th><th>class B1</th></tr>
+	<tr><td>01/10/2024</td><td>123.10</td><td>1.00</td></tr>
+	<tr><td>02/10/2024</td><td>n/a</td><td>1.01</td></tr>
+	<tr><td>Totals</td><td>-</td><td>-</td></tr>
+</table>
+</body></html>`)
+
+	prices, diagnostics, err := ScrapeHistoricalPrices(html, DefaultDateParser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, p := range prices {
+		got = append(got, fmt.Sprintf("%s %s %s", p.ClassName, p.Price.PriceDate, p.Price.NAV))
+	}
+	want := []string{"Class A 2024-10-01 123.10", "Class B1 2024-10-01 1.00", "Class B1 2024-10-02 1.01"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("prices = %q, want %q", got, want)
+	}
+
+	if diagnostics.Parsed != 2 || diagnostics.Dropped() != 1 {
+		t.Errorf("diagnostics = %+v, want 2 rows parsed and the totals row dropped", diagnostics)
+	}
+}
@@ -0,0 +1,83 @@
+package scraper
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RowWarning reports a cell that could not be read. Unless Dropped is set the
+// row is still returned with that field left empty, so one bad cell does not
+// lose the rest of the row.
+type RowWarning struct {
+	// Row is the 0-based index of the row within its table.
+	Row     int
+	Field   string
+	Value   string
+	Message string
+	// Item names the fund or manager the row is about, when the row got far
+	// enough to say.
+	Item string `json:",omitempty"`
+	// Dropped is set when the whole row was left out of the results.
+	Dropped bool `json:",omitempty"`
+}
+
+func (w RowWarning) String() string {
+	return fmt.Sprintf("row %d %s: %s", w.Row, w.Field, w.Message)
+}
+
+// Diagnostics is returned by every page parser next to its results. Parsed
+// counts the rows that made it into the results and Warnings explains every
+// row that was dropped or only partly read.
+type Diagnostics struct {
+	Parsed   int
+	Warnings []RowWarning
+}
+
+func (d *Diagnostics) warn(warning RowWarning) {
+	d.Warnings = append(d.Warnings, warning)
+}
+
+func (d *Diagnostics) drop(warning RowWarning) {
+	warning.Dropped = true
+	d.Warnings = append(d.Warnings, warning)
+}
+
+// Dropped counts the rows left out of the results.
+func (d Diagnostics) Dropped() int {
+	dropped := 0
+	for _, warning := range d.Warnings {
+		if warning.Dropped {
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// maxSummaryReasons caps how many dropped rows Summary spells out.
+const maxSummaryReasons = 3
+
+// Summary describes the page in one line, such as
+// "42 rows parsed, 3 dropped because row 7 Date: ..., 2 cells unreadable".
+func (d Diagnostics) Summary() string {
+	dropped := d.Dropped()
+	summary := fmt.Sprintf("%d rows parsed, %d dropped", d.Parsed, dropped)
+
+	var reasons []string
+	for _, warning := range d.Warnings {
+		if warning.Dropped && len(reasons) < maxSummaryReasons {
+			reasons = append(reasons, warning.String())
+		}
+	}
+	if len(reasons) > 0 {
+		summary += " because " + strings.Join(reasons, "; ")
+		if dropped > len(reasons) {
+			summary += fmt.Sprintf(" and %d more", dropped-len(reasons))
+		}
+	}
+
+	if unreadable := len(d.Warnings) - dropped; unreadable > 0 {
+		summary += fmt.Sprintf(", %d cells unreadable", unreadable)
+	}
+
+	return summary
+}
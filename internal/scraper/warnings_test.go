@@ -0,0 +1,26 @@
+package scraper
+
+import "testing"
+
+func TestDiagnosticsSummary(t *testing.T) {
+	var diagnostics Diagnostics
+	diagnostics.Parsed = 42
+	diagnostics.warn(RowWarning{Row: 3, Field: "NAV", Message: "invalid decimal \"1 204.33\""})
+	for row := 10; row < 15; row++ {
+		diagnostics.drop(RowWarning{Row: row, Field: "Date", Message: "row has no date"})
+	}
+
+	if got := diagnostics.Dropped(); got != 5 {
+		t.Errorf("Dropped() = %d, want 5", got)
+	}
+
+	want := "42 rows parsed, 5 dropped because row 10 Date: row has no date; row 11 Date: row has no date; " +
+		"row 12 Date: row has no date and 2 more, 1 cells unreadable"
+	if got := diagnostics.Summary(); got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+
+	if got, want := (Diagnostics{Parsed: 7}).Summary(), "7 rows parsed, 0 dropped"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}